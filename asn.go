@@ -0,0 +1,119 @@
+package whois
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/joy4eg/whois/internal/adapter"
+)
+
+// asnRoute maps a range of Autonomous System Numbers to the adapter responsible for it.
+type asnRoute struct {
+	first   uint32
+	last    uint32
+	adapter adapter.Adapter
+}
+
+// asnTable is a routing table for Autonomous System Number ranges.
+// Routes are kept sorted by the first number of the range.
+type asnTable struct {
+	routes []asnRoute
+}
+
+// insert adds a route for the [first, last] range to the table.
+func (t *asnTable) insert(first, last uint32, ad adapter.Adapter) {
+	i, _ := slices.BinarySearchFunc(t.routes, first, func(r asnRoute, n uint32) int {
+		return cmpUint32(r.first, n)
+	})
+	t.routes = slices.Insert(t.routes, i, asnRoute{first: first, last: last, adapter: ad})
+}
+
+// lookup returns the adapter of the range containing asn, or nil if no range matches.
+func (t *asnTable) lookup(asn uint32) adapter.Adapter {
+	i, found := slices.BinarySearchFunc(t.routes, asn, func(r asnRoute, n uint32) int {
+		return cmpUint32(r.first, n)
+	})
+	if !found {
+		// The candidate is the last range starting before asn.
+		i--
+	}
+	if i < 0 || asn > t.routes[i].last {
+		return nil
+	}
+	return t.routes[i].adapter
+}
+
+// len returns the number of routes in the table.
+func (t *asnTable) len() int {
+	return len(t.routes)
+}
+
+func cmpUint32(a, b uint32) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// parseASNRange parses a data file key, which is either a single number ("7")
+// or an inclusive range separated by whitespace ("8 27").
+func parseASNRange(s string) (first, last uint32, err error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) > 2 {
+		return 0, 0, errors.Errorf("%q: invalid AS number range", s)
+	}
+
+	bounds := make([]uint32, len(fields))
+	for i, f := range fields {
+		n, err := strconv.ParseUint(f, 10, 32)
+		if err != nil {
+			return 0, 0, errors.Wrapf(err, "%q: invalid AS number", s)
+		}
+		bounds[i] = uint32(n)
+	}
+
+	first, last = bounds[0], bounds[len(bounds)-1]
+	if first > last {
+		return 0, 0, errors.Errorf("%q: invalid AS number range", s)
+	}
+	return first, last, nil
+}
+
+// parseASN parses an Autonomous System Number in asplain ("15169", "AS15169")
+// or asdot ("1.10", "AS1.10") notation, see RFC 5396.
+func parseASN(s string) (uint32, bool) {
+	m := asnRex.FindStringSubmatch(s)
+	if m == nil {
+		return 0, false
+	}
+
+	if m[2] == "" {
+		n, err := strconv.ParseUint(m[1], 10, 32)
+		if err != nil {
+			return 0, false
+		}
+		return uint32(n), true
+	}
+
+	high, err := strconv.ParseUint(m[1], 10, 16)
+	if err != nil {
+		return 0, false
+	}
+	low, err := strconv.ParseUint(m[2], 10, 16)
+	if err != nil {
+		return 0, false
+	}
+	return uint32(high)<<16 | uint32(low), true
+}
+
+// formatASN returns the AS number in the "AS<asplain>" form understood by all RIRs.
+func formatASN(asn uint32) string {
+	return fmt.Sprintf("AS%d", asn)
+}
@@ -0,0 +1,199 @@
+package whois
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+)
+
+var (
+	// notFoundPrefixes are the lowercase line prefixes registries use to report
+	// a domain that is not registered.
+	notFoundPrefixes = []string{
+		"no match for",
+		"no match!!",
+		"no matching record",
+		"no entries found",
+		"no data found",
+		"no object found",
+		"no information available",
+		"domain not found",
+		"domain status: no object found",
+		"status: free",
+		"status: available",
+		"the queried object does not exist",
+		"the domain has not been registered",
+		"this domain name has not been registered",
+	}
+
+	// looseNotFoundPrefixes are generic line prefixes reporting a domain that is not registered
+	// (e.g. Afnic's "NOT FOUND"). Records of registered domains can contain them as well,
+	// e.g. in registrar notes, so they only count for responses without registered fields.
+	looseNotFoundPrefixes = []string{
+		"not found",
+		"not registered",
+	}
+
+	// notFoundSuffixes are the lowercase line suffixes registries use to report
+	// a domain that is not registered (e.g. "example.nl is free").
+	notFoundSuffixes = []string{
+		" is free",
+		" is available for registration",
+		" is available for purchase",
+	}
+
+	// throttledMarkers are lowercase fragments of the responses registries send
+	// when a client exceeds their query limits.
+	throttledMarkers = []string{
+		"limit exceeded",
+		"quota exceeded",
+		"exceeded the maximum",
+		"exceeded your query limit",
+		"too many requests",
+		"too many queries",
+		"rate limit",
+		"excessive querying",
+		"excessive access",
+		"try again later",
+		"please wait",
+	}
+
+	// registeredPrefixes are the lowercase line prefixes of records describing a registered domain.
+	registeredPrefixes = []string{
+		"domain name:",
+		"domain:",
+		"registry domain id:",
+		"creation date:",
+		"created:",
+		"registrar:",
+		"name server:",
+		"nserver:",
+		"[domain name]",
+		"[ドメイン名]",
+		// Records of the RIRs, for IP address and AS number queries.
+		"inetnum:",
+		"inet6num:",
+		"netrange:",
+		"aut-num:",
+		"asnumber:",
+	}
+
+	// fieldRex matches a "key: value" line of a record, lowercased.
+	fieldRex = regexp.MustCompile(`^[a-z][a-z0-9 ./_-]{0,40}:\s*\S`)
+)
+
+const (
+	// maxThrottledLines is the maximum number of lines of a throttling message,
+	// longer responses are records that happen to mention rate limits.
+	maxThrottledLines = 10
+
+	// minRecordFields is the number of "key: value" lines making a response a record.
+	minRecordFields = 3
+)
+
+// detectAvailability reports whether the WHOIS response describes an unregistered domain.
+// Responses that neither describe a registered domain nor report it as not found,
+// such as rate limit messages, return ErrUnknownAvailability.
+func detectAvailability(data []byte) (bool, error) {
+	r := scanResponse(data)
+	switch {
+	case r.notFound:
+		return true, nil
+	case r.registered:
+		return false, nil
+	case r.looseNotFound:
+		return true, nil
+	case r.throttled != "":
+		return false, errors.Wrapf(ErrUnknownAvailability, "server is throttling queries: %q", r.throttled)
+	}
+	return false, ErrUnknownAvailability
+}
+
+// detectThrottling reports whether the WHOIS response is a throttling message rather than a record,
+// and returns the line stating it. Throttling messages are short and not shaped like a record,
+// so records mentioning rate limits in their remarks are not mistaken for them.
+func detectThrottling(data []byte) (string, bool) {
+	r := scanResponse(data)
+	switch {
+	case r.throttled == "",
+		r.notFound || r.looseNotFound || r.registered,
+		r.lines > maxThrottledLines,
+		r.fields >= minRecordFields:
+		return "", false
+	}
+	return r.throttled, true
+}
+
+// responseScan is what scanResponse found in a WHOIS response.
+type responseScan struct {
+	notFound      bool   // a line states the domain is not found
+	looseNotFound bool   // a generic line states something is not found, see looseNotFoundPrefixes
+	registered    bool   // a line is a field of a registered domain record
+	throttled     string // the first line looking like a throttling message, if any
+	lines         int    // number of non-empty lines
+	fields        int    // number of "key: value" lines outside of comments
+}
+
+// scanResponse scans the whole WHOIS response for the lines telling whether the domain
+// is not found, describes a registered domain, or is a throttling message.
+func scanResponse(data []byte) responseScan {
+	var r responseScan
+	hasPrefix := func(line string) func(string) bool {
+		return func(p string) bool { return strings.HasPrefix(line, p) }
+	}
+
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		raw := strings.TrimSpace(s.Text())
+		line := strings.ToLower(strings.TrimSpace(strings.TrimLeft(raw, "%#> \t")))
+		if line == "" {
+			continue
+		}
+		r.lines++
+		if !strings.HasPrefix(raw, "%") && !strings.HasPrefix(raw, "#") && fieldRex.MatchString(line) {
+			r.fields++
+		}
+
+		switch {
+		case slices.ContainsFunc(notFoundPrefixes, hasPrefix(line)),
+			slices.ContainsFunc(notFoundSuffixes, func(p string) bool { return strings.HasSuffix(line, p) }):
+			r.notFound = true
+		case slices.ContainsFunc(looseNotFoundPrefixes, hasPrefix(line)):
+			r.looseNotFound = true
+		case slices.ContainsFunc(registeredPrefixes, hasPrefix(line)):
+			r.registered = true
+		}
+		if r.throttled == "" && slices.ContainsFunc(throttledMarkers, func(m string) bool { return strings.Contains(line, m) }) {
+			r.throttled = line
+		}
+	}
+	return r
+}
+
+func (c *client) Available(ctx context.Context, domain string) (bool, error) {
+	resp, err := c.Lookup(ctx, domain)
+	if errors.Is(err, ErrObjectNotFound) {
+		return true, nil
+	}
+	if errors.Is(err, ErrRateLimited) {
+		return false, errors.Mark(errors.Wrapf(err, "%q", domain), ErrUnknownAvailability)
+	}
+	if err != nil {
+		return false, err
+	}
+	if resp.Protocol == ProtocolRDAP.String() {
+		// RDAP services only return objects that exist.
+		return false, nil
+	}
+
+	available, err := detectAvailability(resp.Raw)
+	if err != nil {
+		return false, errors.Wrapf(err, "%q", domain)
+	}
+	return available, nil
+}
@@ -0,0 +1,52 @@
+package whois
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_detectAvailability(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		data      string
+		available bool
+		wantErr   bool
+	}{
+		{name: "Verisign", data: "No match for \"EXAMPLE-AVAILABLE.COM\".\n>>> Last update of whois database: 2024-01-01T00:00:00Z <<<\n", available: true},
+		{name: "Afnic", data: "%%\n%% NOT FOUND\n%%\n", available: true},
+		{name: "DENIC", data: "Domain: example-available.de\nStatus: free\n", available: true},
+		{name: "Coordination Center", data: "No entries found for the selected source(s).\n", available: true},
+		{name: "SIDN", data: "example-available.nl is free\n", available: true},
+		{name: "EURid", data: "Domain: example-available.eu\nScript: LATIN\n\nStatus: AVAILABLE\n", available: true},
+		{name: "JPRS", data: "No match!!\n\nJPRS WHOIS is provided by Japan Registry Services\n", available: true},
+		{name: "PIR", data: "Domain not found.\n", available: true},
+		{
+			name:      "registered",
+			data:      "Domain Name: EXAMPLE.COM\nRegistry Domain ID: 2336799_DOMAIN_COM-VRSN\nNOTICE: access is subject to rate limits\n",
+			available: false,
+		},
+		{
+			name:      "registered with a not found note",
+			data:      "Domain Name: EXAMPLE.COM\nRegistrar: Example Registrar\nNot found in the DNSSEC trust anchor list\n",
+			available: false,
+		},
+		{name: "DENIC rate limit", data: "% Error: 55000000002 Connection refused; access control limit exceeded\n", wantErr: true},
+		{name: "quota", data: "Quota exceeded, please try again later.\n", wantErr: true},
+		{name: "empty", data: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			available, err := detectAvailability([]byte(tt.data))
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrUnknownAvailability)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.available, available)
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package whois
+
+import (
+	"context"
+	"iter"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// DefaultBatchConcurrency is the default number of lookups of a batch running at once.
+	DefaultBatchConcurrency = 16
+	// DefaultBatchServerConcurrency is the default number of lookups of a batch sent to a server at once.
+	DefaultBatchServerConcurrency = 2
+)
+
+// BatchOptions configures a batch of lookups, see Client.WhoisBatch.
+type BatchOptions struct {
+	// Concurrency is the number of lookups running at once, DefaultBatchConcurrency if zero.
+	Concurrency int
+
+	// ServerConcurrency is the number of lookups sent to the same server at once,
+	// DefaultBatchServerConcurrency if zero.
+	ServerConcurrency int
+
+	// Lookup are the options of every lookup of the batch.
+	Lookup []LookupOption
+}
+
+// BatchResult is the result of a lookup of a batch.
+type BatchResult struct {
+	// Query is the query as given by the caller.
+	Query string
+
+	// Response is the result of the lookup, nil if it failed.
+	Response *Response
+
+	// Err is the error of the lookup, if any.
+	Err error
+}
+
+func (c *client) WhoisBatch(ctx context.Context, queries []string, opts BatchOptions) iter.Seq2[int, BatchResult] {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+	serverConcurrency := opts.ServerConcurrency
+	if serverConcurrency <= 0 {
+		serverConcurrency = DefaultBatchServerConcurrency
+	}
+
+	return func(yield func(int, BatchResult) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		b := &batch{
+			client:  c,
+			queries: queries,
+			opts:    opts.Lookup,
+			limit:   serverConcurrency,
+			results: make(chan batchResult),
+			running: make(map[string]int),
+			pending: make(map[string][]int),
+		}
+		go func() {
+			var wg sync.WaitGroup
+			for range min(concurrency, len(queries)) {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					b.work(ctx)
+				}()
+			}
+			wg.Wait()
+			close(b.results)
+		}()
+
+		for r := range b.results {
+			if !yield(r.i, r.BatchResult) {
+				cancel()
+				// Let the outstanding lookups finish their cancellation.
+				go func() {
+					for range b.results {
+					}
+				}()
+				return
+			}
+		}
+	}
+}
+
+type batchResult struct {
+	i int
+	BatchResult
+}
+
+// batch is the state of a WhoisBatch call. Its workers, as many as the global concurrency,
+// take the queries in order. A query whose server has no free slot is queued for the
+// server, and its lookup is left to the worker releasing the next slot of the server,
+// so the lookups of a busy server never hold the workers the other servers need.
+type batch struct {
+	client  *client
+	queries []string
+	opts    []LookupOption
+	limit   int // lookups per server
+	next    atomic.Int64
+	results chan batchResult
+
+	mu      sync.Mutex
+	running map[string]int   // lookups running per server
+	pending map[string][]int // queries waiting for a slot per server
+}
+
+// work looks the queries up until there are none left.
+func (b *batch) work(ctx context.Context) {
+	o := b.client.lookupOptions(b.opts)
+	for {
+		i := int(b.next.Add(1) - 1)
+		if i >= len(b.queries) {
+			return
+		}
+		if ctx.Err() != nil {
+			b.results <- batchResult{i, BatchResult{Query: b.queries[i], Err: ctx.Err()}}
+			continue
+		}
+
+		server := strings.ToLower(b.client.server(ctx, b.queries[i], o))
+		if server == "" {
+			// Lookups without a known server are not limited.
+			b.lookup(ctx, i)
+			continue
+		}
+		if b.acquire(server, i) {
+			b.serve(ctx, server, i)
+		}
+	}
+}
+
+// acquire takes a slot of the server for the query, or queues the query if there is none.
+func (b *batch) acquire(server string, i int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.running[server] >= b.limit {
+		b.pending[server] = append(b.pending[server], i)
+		return false
+	}
+	b.running[server]++
+	return true
+}
+
+// serve looks the query up with a slot of the server, then the queries queued for
+// the server meanwhile, and releases the slot.
+func (b *batch) serve(ctx context.Context, server string, i int) {
+	for {
+		b.lookup(ctx, i)
+
+		b.mu.Lock()
+		queue := b.pending[server]
+		if len(queue) == 0 {
+			b.running[server]--
+			b.mu.Unlock()
+			return
+		}
+		i, b.pending[server] = queue[0], queue[1:]
+		b.mu.Unlock()
+	}
+}
+
+func (b *batch) lookup(ctx context.Context, i int) {
+	query := b.queries[i]
+	if err := ctx.Err(); err != nil {
+		b.results <- batchResult{i, BatchResult{Query: query, Err: err}}
+		return
+	}
+	resp, err := b.client.Lookup(ctx, query, b.opts...)
+	b.results <- batchResult{i, BatchResult{Query: query, Response: resp, Err: err}}
+}
+
+// server returns the server the lookup of host is sent to first, empty if unknown.
+func (c *client) server(ctx context.Context, host string, o lookupOptions) string {
+	if len(o.servers) > 0 {
+		return o.servers[0]
+	}
+	host, err := Normalize(host)
+	if err != nil {
+		return ""
+	}
+
+	switch o.protocol {
+	case ProtocolRDAP, ProtocolRDAPFallback:
+		if _, _, urls, err := c.rdapRoute(host); err == nil {
+			return urls[0]
+		}
+		return ""
+	}
+
+	ad, _, err := c.route(ctx, host)
+	if err != nil {
+		return ""
+	}
+	return ad.Server()
+}
@@ -0,0 +1,220 @@
+package whois
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientWhoisBatch(t *testing.T) {
+	t.Parallel()
+
+	var running, maxRunning atomic.Int32
+	release := make(chan struct{})
+	slow := serve(t, func(query string) string {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		<-release
+		return "Domain Name: " + query + "\n"
+	})
+	fast := serve(t, func(query string) string {
+		return "Domain Name: " + query + "\n"
+	})
+
+	client, err := newClient()
+	require.NoError(t, err)
+	require.NoError(t, client.LoadDataTLD([]byte(`{"slow": {"host": "`+slow+`"}, "fast": {"host": "`+fast+`"}}`)))
+
+	var queries []string
+	for i := range 6 {
+		queries = append(queries, fmt.Sprintf("example%d.slow", i))
+	}
+	queries = append(queries, "example.fast", "example.invalid-tld")
+
+	seen := make(map[int]BatchResult)
+	for i, r := range client.WhoisBatch(context.Background(), queries, BatchOptions{Concurrency: 3, ServerConcurrency: 2}) {
+		require.Equal(t, queries[i], r.Query)
+		seen[i] = r
+		if len(seen) == 2 {
+			// The slow server holds two slots, the others are served meanwhile.
+			require.Contains(t, seen, 6)
+			require.Contains(t, seen, 7)
+			require.Eventually(t, func() bool { return running.Load() == 2 }, time.Second, time.Millisecond)
+			close(release)
+		}
+	}
+
+	require.Len(t, seen, len(queries))
+	for i, r := range seen {
+		if i == 7 {
+			require.ErrorIs(t, r.Err, ErrCannotMatchTLD)
+			continue
+		}
+		require.NoError(t, r.Err)
+		require.Equal(t, "Domain Name: "+queries[i]+"\n", r.Response.String())
+	}
+	require.EqualValues(t, 2, maxRunning.Load())
+}
+
+func TestClientWhoisBatchConcurrency(t *testing.T) {
+	t.Parallel()
+
+	var running, maxRunning atomic.Int32
+	handler := func(query string) string {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		return "Domain Name: " + query + "\n"
+	}
+
+	client, err := newClient()
+	require.NoError(t, err)
+	var data []string
+	for _, tld := range []string{"one", "two", "three", "four"} {
+		data = append(data, `"`+tld+`": {"host": "`+serve(t, handler)+`"}`)
+	}
+	require.NoError(t, client.LoadDataTLD([]byte("{"+strings.Join(data, ",")+"}")))
+
+	var queries []string
+	for i := range 200 {
+		queries = append(queries, fmt.Sprintf("example%d.%s", i, []string{"one", "two", "three", "four"}[i%4]))
+	}
+
+	var n int
+	for _, r := range client.WhoisBatch(context.Background(), queries, BatchOptions{Concurrency: 3, ServerConcurrency: 100}) {
+		require.NoError(t, r.Err)
+		n++
+	}
+	require.Equal(t, len(queries), n)
+	require.LessOrEqual(t, maxRunning.Load(), int32(3), "the global limit holds across servers")
+}
+
+func TestClientWhoisBatchCancel(t *testing.T) {
+	t.Parallel()
+
+	var queried atomic.Int32
+	server := serve(t, func(query string) string {
+		queried.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return "Domain Name: " + query + "\n"
+	})
+
+	client, err := newClient()
+	require.NoError(t, err)
+
+	queries := make([]string, 20)
+	for i := range queries {
+		queries[i] = fmt.Sprintf("example%d.com", i)
+	}
+	opts := BatchOptions{ServerConcurrency: 1, Lookup: []LookupOption{LookupServers(server)}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var ok, canceled int
+	for _, r := range client.WhoisBatch(ctx, queries, opts) {
+		if r.Err == nil {
+			ok++
+			cancel()
+			continue
+		}
+		require.ErrorIs(t, r.Err, context.Canceled)
+		canceled++
+	}
+	require.Equal(t, len(queries), ok+canceled, "every query gets a result")
+	require.Less(t, ok, len(queries))
+	require.Less(t, int(queried.Load()), len(queries))
+
+	// Stopping the iteration stops the batch as well.
+	var n int
+	for range client.WhoisBatch(context.Background(), queries, opts) {
+		n++
+		break
+	}
+	require.Equal(t, 1, n)
+}
+
+func TestClientWhoisBatchCancelSilent(t *testing.T) {
+	t.Parallel()
+
+	// The server answers example0.com and stays silent for the other queries
+	// until the client hangs up.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+	var open atomic.Int32
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			open.Add(1)
+			go func() {
+				defer open.Add(-1)
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				query, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if strings.TrimSpace(query) == "example0.com" {
+					_, _ = io.WriteString(conn, "Domain Name: example0.com\n")
+					return
+				}
+				_, _ = io.Copy(io.Discard, r)
+			}()
+		}
+	}()
+	server := l.Addr().String()
+
+	client, err := newClient()
+	require.NoError(t, err)
+
+	queries := make([]string, 8)
+	for i := range queries {
+		queries[i] = fmt.Sprintf("example%d.com", i+1)
+	}
+	opts := BatchOptions{Concurrency: 4, ServerConcurrency: 2, Lookup: []LookupOption{LookupServers(server)}}
+
+	// Canceling the context ends the lookups waiting for the server.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start := time.Now()
+	var n int
+	for _, r := range client.WhoisBatch(ctx, queries, opts) {
+		require.ErrorIs(t, r.Err, context.Canceled)
+		n++
+	}
+	require.Equal(t, len(queries), n)
+	require.Less(t, time.Since(start), time.Second)
+	require.Eventually(t, func() bool { return open.Load() == 0 }, time.Second, 10*time.Millisecond)
+
+	// Stopping the iteration hangs up on the server as well.
+	queries[0] = "example0.com"
+	for _, r := range client.WhoisBatch(context.Background(), queries, opts) {
+		require.NoError(t, r.Err)
+		break
+	}
+	require.Eventually(t, func() bool { return open.Load() == 0 }, time.Second, 10*time.Millisecond)
+}
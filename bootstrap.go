@@ -0,0 +1,119 @@
+package whois
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joy4eg/whois/internal/adapter"
+)
+
+// DefaultIANAServer is the WHOIS server of IANA, authoritative for the root zone,
+// IP address and AS number allocations.
+const DefaultIANAServer = "whois.iana.org"
+
+const (
+	// unknownTLDTTL is how long a TLD IANA does not know is remembered, see discover.
+	unknownTLDTTL = time.Hour
+	// maxUnknownTLDs is the number of TLDs IANA does not know remembered at most.
+	maxUnknownTLDs = 1024
+)
+
+// ianaReferralRex matches the server IANA refers to ("refer:") or the WHOIS server
+// of a TLD ("whois:") in IANA responses.
+var ianaReferralRex = regexp.MustCompile(`(?m)^(?:refer|whois):[ \t]*(\S+)`)
+
+// discover asks IANA for the WHOIS server of a TLD missing from the data files.
+// The result is memoized for the life of the client, including TLDs that have no WHOIS server.
+// TLDs IANA does not know come from the caller and are only remembered for a while,
+// see unknownTLDs. Network failures are not memoized.
+func (c *client) discover(ctx context.Context, tld string) (adapter.Adapter, error) {
+	if v, ok := c.Discovered.Load(tld); ok {
+		return v.(discovered).adapter, nil
+	}
+	if c.Unknown.contains(tld) {
+		return nil, nil
+	}
+
+	v, err, _ := c.SF.Do("iana:"+tld, func() (interface{}, error) {
+		result, err := adapter.Standart(c.IANA, nil, c.Transport).Get(ctx, tld)
+		if err != nil {
+			return nil, err
+		}
+
+		var d discovered
+		switch {
+		case strings.Contains(result, "returned 0 objects"):
+			// IANA does not know the TLD.
+			c.Unknown.add(tld)
+			return d, nil
+		case ianaReferralRex.MatchString(result):
+			d.adapter = adapter.Standart(ianaReferralRex.FindStringSubmatch(result)[1], nil, c.Transport)
+		default:
+			d.adapter, err = adapter.None("", nil, c.Transport)
+			if err != nil {
+				return nil, err
+			}
+		}
+		c.Discovered.Store(tld, d)
+		return d, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(discovered).adapter, nil
+}
+
+// discovered is a memoized IANA answer, the adapter is nil for unknown TLDs.
+type discovered struct {
+	adapter adapter.Adapter
+}
+
+// unknownTLDs remembers the TLDs IANA does not know for unknownTLDTTL, up to maxUnknownTLDs
+// of them, so made-up TLDs neither query IANA every time nor grow the client without bounds.
+type unknownTLDs struct {
+	mu      sync.Mutex
+	expires map[string]time.Time
+}
+
+func (u *unknownTLDs) contains(tld string) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	expires, ok := u.expires[tld]
+	if ok && time.Now().After(expires) {
+		delete(u.expires, tld)
+		return false
+	}
+	return ok
+}
+
+func (u *unknownTLDs) add(tld string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	now := time.Now()
+	if u.expires == nil {
+		u.expires = make(map[string]time.Time)
+	}
+	if len(u.expires) >= maxUnknownTLDs {
+		for key, expires := range u.expires {
+			if now.After(expires) {
+				delete(u.expires, key)
+			}
+		}
+	}
+	if len(u.expires) >= maxUnknownTLDs {
+		// Every entry is still valid, forget the one expiring first.
+		var oldest string
+		for key, expires := range u.expires {
+			if oldest == "" || expires.Before(u.expires[oldest]) {
+				oldest = key
+			}
+		}
+		delete(u.expires, oldest)
+	}
+	u.expires[tld] = now.Add(unknownTLDTTL)
+}
@@ -0,0 +1,363 @@
+package whois
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Cache stores the results of lookups, see WithCache.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the value stored for the key, false if there is none or it expired.
+	Get(key string) ([]byte, bool)
+
+	// Set stores the value for the key, for ttl.
+	Set(key string, value []byte, ttl time.Duration)
+
+	// Delete removes the value stored for the key, if any.
+	Delete(key string)
+
+	// Stats returns the usage counters of the cache.
+	Stats() CacheStats
+}
+
+// CacheStats are the usage counters of a cache.
+type CacheStats struct {
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	Sets    uint64 `json:"sets"`
+	Deletes uint64 `json:"deletes"`
+}
+
+// cacheCounters implements the usage counters of the caches.
+type cacheCounters struct {
+	hits, misses, sets, deletes atomic.Uint64
+}
+
+func (c *cacheCounters) get(ok bool) {
+	if ok {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+}
+
+func (c *cacheCounters) Stats() CacheStats {
+	return CacheStats{
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+		Sets:    c.sets.Load(),
+		Deletes: c.deletes.Load(),
+	}
+}
+
+// WithCache caches the results of lookups for ttl, in memory (see NewMemoryCache)
+// unless a storage is given, e.g. NewDiskCache to keep them across restarts.
+func WithCache(ttl time.Duration, storage ...Cache) Option {
+	return func(c *client) {
+		c.Cache.TTL = ttl
+		if len(storage) > 0 {
+			c.Cache.Storage = storage[0]
+			return
+		}
+
+		cache, err := NewMemoryCache()
+		if err != nil {
+			panic(err)
+		}
+		c.Cache.Storage = cache
+	}
+}
+
+// cacheEntry is the cached result of a lookup, either a response or an error.
+type cacheEntry struct {
+	Response *Response   `json:"response,omitempty"`
+	Err      error       `json:"-"`
+	Error    *cacheError `json:"error,omitempty"`
+	Expires  time.Time   `json:"expires"` // end of the TTL, the entry is stale afterwards
+}
+
+// cacheError is the encoded form of a cached error. Typed errors keep their details.
+type cacheError struct {
+	Kind       string        `json:"kind"`
+	Message    string        `json:"message"`
+	Query      string        `json:"query,omitempty"`
+	Server     string        `json:"server,omitempty"`
+	URL        string        `json:"url,omitempty"`
+	Category   string        `json:"category,omitempty"`
+	Reason     string        `json:"reason,omitempty"`
+	RetryAfter time.Duration `json:"retry_after,omitempty"`
+	Limit      int64         `json:"limit,omitempty"`
+}
+
+// cacheErrorKinds are the sentinels cached errors are matched against, in order.
+var cacheErrorKinds = []struct {
+	kind string
+	err  error
+}{
+	{"cannot_match_tld", ErrCannotMatchTLD},
+	{"cannot_match_ip", ErrCannotMatchIP},
+	{"no_rdap_service", ErrNoRDAPService},
+	{"object_not_found", ErrObjectNotFound},
+	{"empty_response", ErrEmptyResponse},
+	{"unknown_availability", ErrUnknownAvailability},
+}
+
+// encode returns the cached form of the lookup result.
+func (e *cacheEntry) encode() ([]byte, error) {
+	if e.Err != nil {
+		e.Error = encodeError(e.Err)
+	}
+	return json.Marshal(e)
+}
+
+// decodeCacheEntry decodes a lookup result encoded by cacheEntry.encode.
+func decodeCacheEntry(data []byte) (*cacheEntry, error) {
+	var e cacheEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, errors.Wrap(err, "failed to decode cache entry")
+	}
+	if e.Error != nil {
+		e.Err = e.Error.decode()
+	} else if e.Response == nil {
+		return nil, errors.New("cache entry has neither a response nor an error")
+	}
+	return &e, nil
+}
+
+func encodeError(err error) *cacheError {
+	ce := &cacheError{Kind: "error", Message: err.Error()}
+
+	var (
+		noServer       *NoServerError
+		webOnly        *WebOnlyError
+		notImplemented *NotImplementedError
+		malformed      *MalformedQueryError
+		timeout        *TimeoutError
+		rateLimited    *RateLimitedError
+		tooLarge       *ResponseTooLargeError
+	)
+	switch {
+	case errors.As(err, &noServer):
+		ce.Kind, ce.Query = "no_server", noServer.Query
+	case errors.As(err, &webOnly):
+		ce.Kind, ce.Query, ce.URL = "web_only", webOnly.Query, webOnly.URL
+	case errors.As(err, &notImplemented):
+		ce.Kind, ce.Query, ce.Category, ce.Reason = "not_implemented", notImplemented.Query, notImplemented.Kind, notImplemented.Reason
+	case errors.As(err, &malformed):
+		ce.Kind, ce.Query, ce.Reason = "malformed_query", malformed.Query, malformed.Reason
+	case errors.As(err, &timeout):
+		ce.Kind, ce.Query, ce.Server, ce.Reason = "timeout", timeout.Query, timeout.Server, timeout.Err.Error()
+	case errors.As(err, &rateLimited):
+		ce.Kind, ce.Server, ce.Reason, ce.RetryAfter = "rate_limited", rateLimited.Server, rateLimited.Message, rateLimited.RetryAfter
+	case errors.As(err, &tooLarge):
+		ce.Kind, ce.Query, ce.Server, ce.Limit = "response_too_large", tooLarge.Query, tooLarge.Server, tooLarge.Limit
+	default:
+		for _, k := range cacheErrorKinds {
+			if errors.Is(err, k.err) {
+				ce.Kind = k.kind
+				break
+			}
+		}
+	}
+	return ce
+}
+
+func (ce *cacheError) decode() error {
+	switch ce.Kind {
+	case "no_server":
+		return &NoServerError{Query: ce.Query}
+	case "web_only":
+		return &WebOnlyError{Query: ce.Query, URL: ce.URL}
+	case "not_implemented":
+		return &NotImplementedError{Query: ce.Query, Kind: ce.Category, Reason: ce.Reason}
+	case "malformed_query":
+		return &MalformedQueryError{Query: ce.Query, Reason: ce.Reason}
+	case "timeout":
+		return &TimeoutError{Query: ce.Query, Server: ce.Server, Err: errors.New(ce.Reason)}
+	case "rate_limited":
+		return &RateLimitedError{Server: ce.Server, Message: ce.Reason, RetryAfter: ce.RetryAfter}
+	case "response_too_large":
+		return &ResponseTooLargeError{Query: ce.Query, Server: ce.Server, Limit: ce.Limit}
+	}
+	for _, k := range cacheErrorKinds {
+		if ce.Kind == k.kind {
+			return &markedError{message: ce.Message, err: k.err}
+		}
+	}
+	return errors.New(ce.Message)
+}
+
+// markedError is a decoded error matching the sentinel it was cached for.
+type markedError struct {
+	message string
+	err     error
+}
+
+func (e *markedError) Error() string {
+	return e.message
+}
+
+func (e *markedError) Unwrap() error {
+	return e.err
+}
+
+// stale reports whether the entry outlived its TTL and is served within the grace period.
+func (e *cacheEntry) stale() bool {
+	return e.Response != nil && !e.Expires.IsZero() && time.Now().After(e.Expires)
+}
+
+// result returns the cached response marked as such, or the cached error.
+func (e *cacheEntry) result() (*Response, error) {
+	if e.Err != nil {
+		return nil, e.Err
+	}
+	cached := *e.Response
+	cached.Cached = true
+	cached.Stale = e.stale()
+	return &cached, nil
+}
+
+// WithStaleWhileRevalidate keeps the cached responses for grace past their TTL. Lookups
+// in the grace period get the stale response right away (Response.Stale) and trigger a single
+// background lookup refreshing it. Failures are not served stale.
+// It has no effect without WithCache.
+func WithStaleWhileRevalidate(grace time.Duration) Option {
+	return func(c *client) {
+		c.Cache.Grace = grace
+	}
+}
+
+// cached returns the cache entry of the key, dropping it if it cannot be decoded.
+func (c *client) cached(ctx context.Context, key string) (*cacheEntry, bool) {
+	data, ok := c.Cache.Storage.Get(key)
+	if !ok {
+		return nil, false
+	}
+
+	entry, err := decodeCacheEntry(data)
+	if err != nil {
+		slog.WarnContext(ctx, "dropping invalid cache entry", "key", key, "err", err)
+		c.Cache.Storage.Delete(key)
+		return nil, false
+	}
+	return entry, true
+}
+
+// store caches the result of a lookup, responses are kept for the grace period past their TTL.
+func (c *client) store(ctx context.Context, key string, resp *Response, err error) {
+	ttl := c.cacheTTL(ctx, resp, err)
+	if ttl <= 0 {
+		return
+	}
+
+	entry := &cacheEntry{Response: resp, Err: err}
+	if err == nil && c.Cache.Grace > 0 {
+		entry.Expires = time.Now().Add(ttl)
+		ttl += c.Cache.Grace
+	}
+
+	data, err := entry.encode()
+	if err != nil {
+		slog.WarnContext(ctx, "failed to encode cache entry", "key", key, "err", err)
+		return
+	}
+	c.Cache.Storage.Set(key, data, ttl)
+}
+
+// revalidate refreshes a stale cache entry in the background. Concurrent refreshes
+// of the same entry are deduplicated, and a failing refresh keeps the stale entry.
+func (c *client) revalidate(ctx context.Context, key, host string, o lookupOptions) {
+	ctx = context.WithoutCancel(ctx)
+	c.SF.DoChan("revalidate:"+key, func() (interface{}, error) {
+		resp, err := c.lookup(ctx, host, o)
+		if err != nil {
+			slog.DebugContext(ctx, "failed to revalidate cache entry", "host", host, "err", err)
+			return nil, err
+		}
+		c.store(ctx, key, resp, nil)
+		return resp, nil
+	})
+}
+
+// WithNegativeCacheTTL caches deterministic failures for ttl, e.g. ErrCannotMatchTLD,
+// ErrNoWhoisServer, ErrWebOnly or ErrNotImplemented. They are not cached by default.
+// It has no effect without WithCache.
+func WithNegativeCacheTTL(ttl time.Duration) Option {
+	return func(c *client) {
+		c.Cache.NegativeTTL = ttl
+	}
+}
+
+// WithTransientCacheTTL caches transient failures for ttl, e.g. timeouts, refused connections
+// or throttled queries, so the failing server is not hammered. Keep it short, they are not cached
+// by default. Throttling responses are never cached as results, only the ErrRateLimited error is.
+// It has no effect without WithCache.
+func WithTransientCacheTTL(ttl time.Duration) Option {
+	return func(c *client) {
+		c.Cache.TransientTTL = ttl
+	}
+}
+
+// WithNotFoundCacheTTL caches the responses of domains that are not registered (and the
+// ErrObjectNotFound RDAP error) for ttl instead of the WithCache TTL, e.g. for availability sweeps.
+// It has no effect without WithCache.
+func WithNotFoundCacheTTL(ttl time.Duration) Option {
+	return func(c *client) {
+		c.Cache.NotFoundTTL = ttl
+	}
+}
+
+// cacheTTL returns how long the result of a lookup made with ctx can be cached, zero if it cannot.
+func (c *client) cacheTTL(ctx context.Context, resp *Response, err error) time.Duration {
+	notFoundTTL := c.Cache.NotFoundTTL
+	if notFoundTTL == 0 {
+		notFoundTTL = c.Cache.TTL
+	}
+
+	switch {
+	case err == nil:
+		if resp.Protocol == ProtocolWHOIS.String() {
+			if available, err := detectAvailability(resp.Raw); err == nil && available {
+				return notFoundTTL
+			}
+		}
+		return c.Cache.TTL
+
+	case errors.Is(err, ErrObjectNotFound):
+		return notFoundTTL
+
+	case errors.Is(err, ErrCannotMatchTLD),
+		errors.Is(err, ErrCannotMatchIP),
+		errors.Is(err, ErrNoWhoisServer),
+		errors.Is(err, ErrNoRDAPService),
+		errors.Is(err, ErrWebOnly),
+		errors.Is(err, ErrNotImplemented),
+		errors.Is(err, ErrMalformedQuery):
+		return c.Cache.NegativeTTL
+
+	case errors.Is(err, context.Canceled), errors.Is(err, errLookupExpired), callerExpired(ctx):
+		// The caller gave up or ran out of time, the failure says nothing about the server
+		// and the next lookup, with a longer deadline, may well succeed.
+		return 0
+	}
+	return c.Cache.TransientTTL
+}
+
+// errLookupExpired marks the failures of lookups whose context expired, see client.lookup.
+var errLookupExpired = errors.New("lookup context expired")
+
+// callerExpired reports whether the context of the caller is done, or its deadline passed
+// (network timeouts set from the deadline may fire before the context notices).
+func callerExpired(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return true
+	}
+	deadline, ok := ctx.Deadline()
+	return ok && !time.Now().Before(deadline)
+}
@@ -0,0 +1,130 @@
+package whois
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// diskHeaderSize is the size of the expiry time (Unix nanoseconds) preceding the value.
+const diskHeaderSize = 8
+
+// DiskCache is a persistent cache keeping one file per key.
+type DiskCache struct {
+	cacheCounters
+	dir string
+}
+
+// NewDiskCache returns a persistent cache storing its entries in dir, one file per key
+// holding the expiry time and the value. The directory is created if it does not exist
+// and can be shared by several processes, e.g. the API service and batch jobs.
+// Expired entries are removed when they are read, or by DiskCache.Prune.
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.Wrapf(err, "%q: failed to create cache directory", dir)
+	}
+	return &DiskCache{dir: dir}, nil
+}
+
+// path returns the file of the key, spread over 256 subdirectories.
+func (c *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(c.dir, name[:2], name)
+}
+
+func (c *DiskCache) Get(key string) ([]byte, bool) {
+	value, ok := c.read(c.path(key))
+	c.get(ok)
+	return value, ok
+}
+
+// read returns the value of an entry file, removing the file if it expired.
+func (c *DiskCache) read(path string) ([]byte, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			slog.Warn("failed to read cache entry", "path", path, "err", err)
+		}
+		return nil, false
+	}
+
+	if len(data) < diskHeaderSize {
+		_ = os.Remove(path)
+		return nil, false
+	}
+	expires := time.Unix(0, int64(binary.BigEndian.Uint64(data)))
+	if time.Now().After(expires) {
+		_ = os.Remove(path)
+		return nil, false
+	}
+	return data[diskHeaderSize:], true
+}
+
+// Set writes the entry to a temporary file first, so readers never see a partial entry.
+func (c *DiskCache) Set(key string, value []byte, ttl time.Duration) {
+	c.sets.Add(1)
+	if err := c.write(c.path(key), value, ttl); err != nil {
+		slog.Warn("failed to write cache entry", "key", key, "err", err)
+	}
+}
+
+func (c *DiskCache) write(path string, value []byte, ttl time.Duration) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	var header [diskHeaderSize]byte
+	binary.BigEndian.PutUint64(header[:], uint64(time.Now().Add(ttl).UnixNano()))
+	if _, err := f.Write(header[:]); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(value); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func (c *DiskCache) Delete(key string) {
+	c.deletes.Add(1)
+	if err := os.Remove(c.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		slog.Warn("failed to delete cache entry", "key", key, "err", err)
+	}
+}
+
+// Prune removes the expired entries and returns how many were removed.
+func (c *DiskCache) Prune() (int, error) {
+	var removed int
+	err := filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || strings.HasPrefix(d.Name(), ".tmp-") {
+			return err
+		}
+		if _, ok := c.read(path); !ok {
+			removed++
+		}
+		return nil
+	})
+	if err != nil {
+		return removed, errors.Wrapf(err, "%q: failed to prune cache", c.dir)
+	}
+	return removed, nil
+}
@@ -0,0 +1,52 @@
+package whois
+
+import (
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/dgraph-io/ristretto/v2"
+)
+
+// memoryCache is an in-process cache backed by ristretto.
+type memoryCache struct {
+	cacheCounters
+	cache *ristretto.Cache[string, []byte]
+}
+
+// NewMemoryCache returns an in-process cache, its entries are lost when the process exits.
+// The cache holds up to 1GB of entries, the least valuable ones are evicted first.
+func NewMemoryCache() (Cache, error) {
+	cache, err := ristretto.NewCache(&ristretto.Config[string, []byte]{
+		NumCounters: 1e7,     // number of keys to track frequency of (10M).
+		MaxCost:     1 << 30, // maximum cost of cache (1GB).
+		BufferItems: 64,      // number of keys per Get buffer.
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create memory cache")
+	}
+
+	return &memoryCache{cache: cache}, nil
+}
+
+func (c *memoryCache) Get(key string) ([]byte, bool) {
+	value, ok := c.cache.Get(key)
+	c.get(ok)
+	return value, ok
+}
+
+// Set stores the value, it is visible to Get shortly after Set returns
+// (the writes are buffered), or never if ristretto rejects it.
+func (c *memoryCache) Set(key string, value []byte, ttl time.Duration) {
+	c.sets.Add(1)
+	c.cache.SetWithTTL(key, value, int64(len(value)), ttl)
+}
+
+func (c *memoryCache) Delete(key string) {
+	c.deletes.Add(1)
+	c.cache.Del(key)
+}
+
+func (c *memoryCache) Close() error {
+	c.cache.Close()
+	return nil
+}
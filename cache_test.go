@@ -0,0 +1,271 @@
+package whois
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// syncMemoryCache is a memory cache waiting for every write to be applied,
+// for the tests expecting the entries to be visible as soon as they are stored.
+type syncMemoryCache struct {
+	*memoryCache
+}
+
+func (c syncMemoryCache) Set(key string, value []byte, ttl time.Duration) {
+	c.memoryCache.Set(key, value, ttl)
+	c.cache.Wait()
+}
+
+// withSyncCache is WithCache with a syncMemoryCache.
+func withSyncCache(t *testing.T, ttl time.Duration) Option {
+	t.Helper()
+
+	cache, err := NewMemoryCache()
+	require.NoError(t, err)
+	return WithCache(ttl, syncMemoryCache{cache.(*memoryCache)})
+}
+
+func TestClientCacheFailures(t *testing.T) {
+	t.Parallel()
+
+	var queries atomic.Int32
+	server := serve(t, func(query string) string {
+		queries.Add(1)
+		return "" // empty responses are transient failures
+	})
+
+	client, err := newClient(
+		withSyncCache(t, time.Hour),
+		WithNegativeCacheTTL(time.Hour),
+		WithTransientCacheTTL(time.Minute),
+	)
+	require.NoError(t, err)
+	defer client.Close()
+	require.NoError(t, client.LoadDataTLD([]byte(`{"noserver": {"adapter": "none"}}`)))
+
+	for range 2 {
+		_, err = client.Whois(context.Background(), "example.com", server)
+		require.ErrorIs(t, err, ErrEmptyResponse)
+	}
+	require.EqualValues(t, 1, queries.Load(), "transient failures must be cached")
+
+	for range 2 {
+		_, err = client.Whois(context.Background(), "example.noserver")
+		require.ErrorIs(t, err, ErrNoWhoisServer)
+	}
+	_, ok := client.Cache.Storage.Get((&lookupOptions{}).key("example.noserver"))
+	require.True(t, ok, "deterministic failures must be cached")
+}
+
+func TestClientCacheTTL(t *testing.T) {
+	t.Parallel()
+
+	client, err := newClient()
+	require.NoError(t, err)
+	client.Cache.TTL = time.Hour
+	client.Cache.NegativeTTL = 2 * time.Hour
+	client.Cache.TransientTTL = time.Minute
+	client.Cache.NotFoundTTL = 10 * time.Minute
+
+	registered := &Response{Protocol: "whois", Raw: []byte("Domain Name: EXAMPLE.COM\n")}
+	notFound := &Response{Protocol: "whois", Raw: []byte("No match for \"EXAMPLE-AVAILABLE.COM\".\n")}
+	rdap := &Response{Protocol: "rdap", Raw: []byte(`{"objectClassName": "domain"}`)}
+
+	tests := []struct {
+		name string
+		resp *Response
+		err  error
+		want time.Duration
+	}{
+		{name: "registered", resp: registered, want: time.Hour},
+		{name: "not found", resp: notFound, want: 10 * time.Minute},
+		{name: "rdap", resp: rdap, want: time.Hour},
+		{name: "rdap not found", err: errors.Wrapf(ErrObjectNotFound, "%q", "example.com"), want: 10 * time.Minute},
+		{name: "unknown TLD", err: errors.Wrapf(ErrCannotMatchTLD, "%q", "example.invalid"), want: 2 * time.Hour},
+		{name: "no server", err: &NoServerError{Query: "example.noserver"}, want: 2 * time.Hour},
+		{name: "web only", err: &WebOnlyError{Query: "example.webonly", URL: "https://whois.example/"}, want: 2 * time.Hour},
+		{name: "timeout", err: &TimeoutError{Query: "example.com", Server: "whois.example"}, want: time.Minute},
+		{name: "rate limited", err: &RateLimitedError{Server: "whois.example"}, want: time.Minute},
+		{name: "canceled", err: context.Canceled, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, client.cacheTTL(context.Background(), tt.resp, tt.err))
+		})
+	}
+
+	// The caller running out of time is not a failure of the server.
+	ctx, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+	timeout := &TimeoutError{Query: "example.com", Server: "whois.example", Err: context.DeadlineExceeded}
+	require.Zero(t, client.cacheTTL(ctx, nil, timeout))
+	require.Zero(t, client.cacheTTL(ctx, nil, errors.Wrapf(context.DeadlineExceeded, "%q", "whois.example")))
+}
+
+func TestClientCacheCallerDeadline(t *testing.T) {
+	t.Parallel()
+
+	var queries atomic.Int32
+	release := make(chan struct{})
+	server := serve(t, func(query string) string {
+		if queries.Add(1) == 1 {
+			<-release
+		}
+		return "Domain Name: " + query + "\n"
+	})
+	defer close(release)
+
+	client, err := newClient(withSyncCache(t, time.Hour), WithTransientCacheTTL(time.Hour))
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = client.Whois(ctx, "example.com", server)
+	require.ErrorIs(t, err, ErrTimeout)
+
+	result, err := client.Whois(context.Background(), "example.com", server)
+	require.NoError(t, err, "timeouts of the caller must not be cached")
+	require.Equal(t, "Domain Name: example.com\n", result)
+}
+
+func TestDiskCache(t *testing.T) {
+	t.Parallel()
+
+	cache, err := NewDiskCache(t.TempDir())
+	require.NoError(t, err)
+
+	_, ok := cache.Get("example.com")
+	require.False(t, ok)
+
+	cache.Set("example.com", []byte("value"), time.Hour)
+	value, ok := cache.Get("example.com")
+	require.True(t, ok)
+	require.Equal(t, "value", string(value))
+
+	cache.Set("expired.com", []byte("value"), -time.Second)
+	cache.Set("expiring.com", []byte("value"), -time.Second)
+	_, ok = cache.Get("expired.com")
+	require.False(t, ok)
+
+	removed, err := cache.Prune()
+	require.NoError(t, err)
+	require.Equal(t, 1, removed)
+
+	cache.Delete("example.com")
+	_, ok = cache.Get("example.com")
+	require.False(t, ok)
+
+	require.Equal(t, CacheStats{Hits: 1, Misses: 3, Sets: 3, Deletes: 1}, cache.Stats())
+}
+
+func TestClientDiskCache(t *testing.T) {
+	t.Parallel()
+
+	var queries atomic.Int32
+	server := serve(t, func(query string) string {
+		queries.Add(1)
+		return "Domain Name: " + query + "\n"
+	})
+	dir := t.TempDir()
+
+	// Every client stands for a process, the entries survive restarts.
+	for range 2 {
+		cache, err := NewDiskCache(dir)
+		require.NoError(t, err)
+		client, err := newClient(WithCache(time.Hour, cache), WithNegativeCacheTTL(time.Hour))
+		require.NoError(t, err)
+		require.NoError(t, client.LoadDataTLD([]byte(`{"webonly": {"adapter": "web", "url": "https://whois.example/"}}`)))
+
+		resp, err := client.Lookup(context.Background(), "example.com", LookupServers(server))
+		require.NoError(t, err)
+		require.Equal(t, "Domain Name: example.com\n", string(resp.Raw))
+
+		_, err = client.Whois(context.Background(), "example.webonly")
+		var webOnly *WebOnlyError
+		require.ErrorAs(t, err, &webOnly)
+		require.Equal(t, "https://whois.example/", webOnly.URL)
+	}
+	require.EqualValues(t, 1, queries.Load())
+}
+
+func Test_cacheEntry(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err error
+		is  error
+	}{
+		{err: &NoServerError{Query: "example.noserver"}, is: ErrNoWhoisServer},
+		{err: &WebOnlyError{Query: "example.webonly", URL: "https://whois.example/"}, is: ErrWebOnly},
+		{err: &NotImplementedError{Query: "2001::1", Kind: "teredo", Reason: "Teredo address"}, is: ErrNotImplemented},
+		{err: &MalformedQueryError{Query: "256.in-addr.arpa", Reason: "invalid octet"}, is: ErrMalformedQuery},
+		{err: &TimeoutError{Query: "example.com", Server: "whois.example", Err: context.DeadlineExceeded}, is: ErrTimeout},
+		{err: &RateLimitedError{Server: "whois.example", Message: "limit exceeded", RetryAfter: time.Minute}, is: ErrRateLimited},
+		{err: &ResponseTooLargeError{Query: "example.com", Server: "whois.example", Limit: 1 << 20}, is: ErrResponseTooLarge},
+		{err: errors.Wrapf(ErrCannotMatchTLD, "%q", "example.invalid"), is: ErrCannotMatchTLD},
+		{err: errors.Wrapf(ErrEmptyResponse, "%q", "whois.example"), is: ErrEmptyResponse},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			data, err := (&cacheEntry{Err: tt.err}).encode()
+			require.NoError(t, err)
+			entry, err := decodeCacheEntry(data)
+			require.NoError(t, err)
+			require.Equal(t, tt.err.Error(), entry.Err.Error())
+			require.ErrorIs(t, entry.Err, tt.is)
+		})
+	}
+}
+
+func TestClientStaleWhileRevalidate(t *testing.T) {
+	t.Parallel()
+
+	var queries atomic.Int32
+	release := make(chan struct{})
+	server := serve(t, func(query string) string {
+		n := queries.Add(1)
+		if n > 1 {
+			<-release
+		}
+		return fmt.Sprintf("Domain Name: %s\nVersion: %d\n", query, n)
+	})
+
+	client, err := newClient(withSyncCache(t, 50*time.Millisecond), WithStaleWhileRevalidate(time.Hour))
+	require.NoError(t, err)
+	defer client.Close()
+
+	resp, err := client.Lookup(context.Background(), "example.com", LookupServers(server))
+	require.NoError(t, err)
+	require.Contains(t, string(resp.Raw), "Version: 1\n")
+	require.False(t, resp.Stale)
+
+	time.Sleep(100 * time.Millisecond)
+	for range 5 {
+		resp, err = client.Lookup(context.Background(), "example.com", LookupServers(server))
+		require.NoError(t, err)
+		require.Contains(t, string(resp.Raw), "Version: 1\n")
+		require.True(t, resp.Cached)
+		require.True(t, resp.Stale)
+	}
+
+	close(release)
+	require.Eventually(t, func() bool {
+		resp, err := client.Lookup(context.Background(), "example.com", LookupServers(server))
+		return err == nil && !resp.Stale
+	}, time.Second, 5*time.Millisecond)
+	require.EqualValues(t, 2, queries.Load(), "stale entries must be refreshed once")
+
+	resp, err = client.Lookup(context.Background(), "example.com", LookupServers(server))
+	require.NoError(t, err)
+	require.Contains(t, string(resp.Raw), "Version: 2\n")
+	require.True(t, resp.Cached)
+}
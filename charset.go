@@ -0,0 +1,82 @@
+package whois
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cockroachdb/errors"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// fallbackCharset is the charset of invalid UTF-8 responses from servers without a configured one.
+// Windows-1252 is a superset of the printable ISO-8859-1 characters, used by most non-UTF-8 registries.
+const fallbackCharset = "windows-1252"
+
+// iso2022JPEscapes are the escape sequences switching ISO-2022-JP to a Japanese character set.
+var iso2022JPEscapes = [][]byte{
+	[]byte("\x1b$@"), // JIS C 6226-1978
+	[]byte("\x1b$B"), // JIS X 0208-1983
+	[]byte("\x1b(J"), // JIS X 0201 Roman
+}
+
+// validateCharset checks that the charset, a name from the WHATWG Encoding Standard
+// (e.g. "iso-8859-1" or "shift_jis"), is supported.
+func validateCharset(charset string) error {
+	if _, err := htmlindex.Get(charset); err != nil {
+		return errors.Wrapf(err, "%q: unsupported charset", charset)
+	}
+	return nil
+}
+
+// decodeResponse returns the response as UTF-8 together with the charset it was decoded from,
+// empty if the response already was UTF-8 (or plain ASCII).
+//
+// ISO-2022-JP responses are recognized by their escape sequences, since they are valid ASCII.
+// Other responses that are not valid UTF-8 are decoded with the charset configured for
+// the server, or as Windows-1252 if none is. Bytes the charset cannot decode become U+FFFD.
+//
+// Parameters:
+//   - raw: The response as received from the server
+//   - charset: The charset configured for the server, empty if unknown
+//
+// Returns:
+//   - string: The response as valid UTF-8
+//   - string: The canonical name of the charset the response was decoded from, e.g. "shift_jis"
+func decodeResponse(raw []byte, charset string) (string, string) {
+	switch {
+	case isISO2022JP(raw):
+		charset = "iso-2022-jp"
+	case utf8.Valid(raw):
+		return string(raw), ""
+	case charset == "":
+		charset = fallbackCharset
+	}
+
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return strings.ToValidUTF8(string(raw), string(utf8.RuneError)), ""
+	}
+	text, err := enc.NewDecoder().Bytes(raw)
+	if err != nil {
+		return strings.ToValidUTF8(string(raw), string(utf8.RuneError)), ""
+	}
+
+	name, err := htmlindex.Name(enc)
+	if err != nil {
+		name = charset
+	}
+	return strings.ToValidUTF8(string(text), string(utf8.RuneError)), name
+}
+
+func isISO2022JP(raw []byte) bool {
+	if bytes.IndexByte(raw, 0x1b) < 0 {
+		return false
+	}
+	for _, esc := range iso2022JPEscapes {
+		if bytes.Contains(raw, esc) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package whois
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/joy4eg/whois/internal/data"
+)
+
+func Test_decodeResponse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		raw         string
+		charset     string
+		want        string
+		wantCharset string
+	}{
+		{name: "ascii", raw: "Domain Name: EXAMPLE.JP\n", want: "Domain Name: EXAMPLE.JP\n"},
+		{name: "utf-8", raw: "Registrant: 日本\n", charset: "shift_jis", want: "Registrant: 日本\n"},
+		{name: "iso-2022-jp", raw: "Registrant: \x1b$BF|K\\\x1b(B\n", want: "Registrant: 日本\n", wantCharset: "iso-2022-jp"},
+		{name: "shift_jis", raw: "Registrant: \x93\xfa\x96{\n", charset: "shift_jis", want: "Registrant: 日本\n", wantCharset: "shift_jis"},
+		{name: "euc-jp", raw: "Registrant: \xc6\xfc\xcb\xdc\n", charset: "euc-jp", want: "Registrant: 日本\n", wantCharset: "euc-jp"},
+		{name: "iso-8859-1", raw: "City: M\xfcnchen\n", charset: "iso-8859-1", want: "City: München\n", wantCharset: "windows-1252"},
+		{name: "fallback", raw: "City: M\xfcnchen\n", want: "City: München\n", wantCharset: "windows-1252"},
+		{name: "invalid shift_jis", raw: "Registrant: \x93\n", charset: "shift_jis", want: "Registrant: �\n", wantCharset: "shift_jis"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, charset := decodeResponse([]byte(tt.raw), tt.charset)
+			require.True(t, utf8.ValidString(got))
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.wantCharset, charset)
+		})
+	}
+}
+
+func TestClientCharset(t *testing.T) {
+	t.Parallel()
+
+	raw := "Domain Name: EXAMPLE.JP\nRegistrant: \x93\xfa\x96{\n"
+	server := serve(t, func(query string) string {
+		return raw
+	})
+
+	client, err := newClient()
+	require.NoError(t, err)
+	require.NoError(t, client.LoadDataTLD([]byte(`{"jp": {"host": "`+server+`", "charset": "shift_jis"}}`)))
+
+	resp, err := client.Lookup(context.Background(), "example.jp")
+	require.NoError(t, err)
+	require.Equal(t, "Domain Name: EXAMPLE.JP\nRegistrant: 日本\n", string(resp.Raw))
+	require.Equal(t, "shift_jis", resp.Charset)
+	require.Nil(t, resp.Original)
+
+	resp, err = client.Lookup(context.Background(), "example.jp", LookupOriginal())
+	require.NoError(t, err)
+	require.Equal(t, "Domain Name: EXAMPLE.JP\nRegistrant: 日本\n", string(resp.Raw))
+	require.Equal(t, raw, string(resp.Original))
+
+	// Servers without a configured charset fall back to Windows-1252.
+	resp, err = client.Lookup(context.Background(), "example.jp", LookupServers(server))
+	require.NoError(t, err)
+	require.Equal(t, "windows-1252", resp.Charset)
+	require.True(t, utf8.Valid(resp.Raw))
+
+	require.Error(t, client.LoadDataTLD([]byte(`{"invalid": {"host": "whois.example", "charset": "klingon"}}`)))
+}
+
+func TestClientCharsetISO2022JP(t *testing.T) {
+	t.Parallel()
+
+	// JPRS answers port 43 queries in ISO-2022-JP.
+	raw := "[\x1b$B%I%a%$%sL>\x1b(B]                    EXAMPLE.JP\n" +
+		"[\x1b$BEPO?<TL>\x1b(B]                      \x1b$BF|K\\%l%8%9%H%j%5!<%S%9\x1b(B\n"
+	server := serve(t, func(query string) string {
+		if query != "example.jp/e" {
+			return ""
+		}
+		return raw
+	})
+
+	client, err := newClient()
+	require.NoError(t, err)
+	require.NoError(t, client.LoadDataTLD([]byte(`{"jp": {"host": "`+server+`", "adapter": "formatted", "format": "%s/e"}}`)))
+
+	resp, err := client.Lookup(context.Background(), "example.jp")
+	require.NoError(t, err)
+	require.Equal(t, "[ドメイン名]                    EXAMPLE.JP\n[登録者名]                      日本レジストリサービス\n", string(resp.Raw))
+	require.Equal(t, "iso-2022-jp", resp.Charset)
+	require.Nil(t, resp.Original)
+
+	resp, err = client.Lookup(context.Background(), "example.jp", LookupOriginal())
+	require.NoError(t, err)
+	require.Equal(t, raw, string(resp.Original))
+}
+
+func TestClientCharsetKR(t *testing.T) {
+	t.Parallel()
+
+	// KISA answers in EUC-KR, which would come out as Windows-1252 mojibake.
+	raw := "\xb5\xb5\xb8\xde\xc0\xce\xc0\xcc\xb8\xa7                  : example.kr\n" +
+		"\xb5\xee\xb7\xcf\xc0\xce                      : \xc7\xd1\xb1\xb9\xc0\xce\xc5\xcd\xb3\xdd\xc1\xf8\xc8\xef\xbf\xf8\n"
+	server := serve(t, func(query string) string {
+		return raw
+	})
+
+	// The embedded .kr entry, pointed at the local server.
+	tlds, err := data.Files.ReadFile("tld.json")
+	require.NoError(t, err)
+	var entries map[string]map[string]any
+	require.NoError(t, json.Unmarshal(tlds, &entries))
+	entry := entries["kr"]
+	require.Equal(t, "euc-kr", entry["charset"])
+	entry["host"] = server
+	config, err := json.Marshal(map[string]any{"kr": entry})
+	require.NoError(t, err)
+
+	client, err := newClient()
+	require.NoError(t, err)
+	require.NoError(t, client.LoadDataTLD(config))
+
+	resp, err := client.Lookup(context.Background(), "example.kr")
+	require.NoError(t, err)
+	require.Equal(t, "도메인이름                  : example.kr\n등록인                      : 한국인터넷진흥원\n", string(resp.Raw))
+	require.Equal(t, "euc-kr", resp.Charset)
+
+	// KRNIC address blocks are decoded the same way.
+	ad, _, err := client.route(context.Background(), "59.0.0.1")
+	require.NoError(t, err)
+	require.Equal(t, "whois.nic.or.kr", ad.Server())
+	require.Equal(t, "euc-kr", client.Charsets[ad])
+}
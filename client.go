@@ -1,7 +1,9 @@
-// Package whois provides functionality for querying WHOIS information for domain names.
+// Package whois provides functionality for querying WHOIS information for domain names,
+// IPv4/IPv6 addresses and Autonomous System Numbers.
 //
 // The package implements a WHOIS client that supports multiple TLD (Top Level Domain) adapters
-// and can automatically detect the appropriate WHOIS server based on the domain name.
+// and can automatically detect the appropriate WHOIS server based on the domain name
+// or the address block an IP address belongs to (the number range for AS numbers).
 //
 // The client supports loading TLD configuration data from JSON files and provides
 // flexible adapter options for different WHOIS server implementations.
@@ -18,46 +20,67 @@ package whois
 import (
 	"cmp"
 	"context"
-	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
 	"log/slog"
+	"net/http"
+	"net/netip"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/cockroachdb/errors"
-	"github.com/dgraph-io/ristretto/v2"
 	"github.com/tidwall/gjson"
 	"golang.org/x/sync/singleflight"
 
 	"github.com/joy4eg/whois/internal/adapter"
 	"github.com/joy4eg/whois/internal/data"
+	"github.com/joy4eg/whois/internal/rdap"
 )
 
 // client is a whois client that implements the Query interface.
 type client struct {
-	TLDs  map[string]adapter.Adapter
-	SF    singleflight.Group
+	TLDs       map[string]adapter.Adapter
+	Alternates map[adapter.Adapter][]adapter.Adapter // alternate servers of the data file entries
+	Charsets   map[adapter.Adapter]string            // charsets of the data file entries, see decodeResponse
+	Discovered sync.Map                              // TLDs bootstrapped through IANA, see discover
+	Unknown    unknownTLDs                           // TLDs IANA does not know, see discover
+	IPs        ipTable
+	ASNs       asnTable
+	MaxHops    int      // number of referrals to follow, see WithFollowReferrals
+	Protocol   Protocol // protocol strategy, see WithProtocol
+	IANA       string
+	SF         singleflight.Group
+	Transport  *adapter.Transport // WHOIS network settings, see WithDialer
+	Limiter    rateLimiter        // see WithRateLimit
+	Retry      RetryPolicy        // see WithRetry
+
+	Bootstrap      *rdap.Bootstrap // RDAP service discovery
+	BootstrapFiles fs.FS           // RDAP bootstrap files overriding the embedded ones
+	HTTP           *http.Client    // RDAP HTTP client
+
 	Cache struct {
-		TTL     time.Duration
-		Storage *ristretto.Cache[string, string]
+		TTL          time.Duration
+		NegativeTTL  time.Duration // see WithNegativeCacheTTL
+		TransientTTL time.Duration // see WithTransientCacheTTL
+		NotFoundTTL  time.Duration // see WithNotFoundCacheTTL, TTL if zero
+		Grace        time.Duration // see WithStaleWhileRevalidate
+		Storage      Cache
 	}
 }
 
 // Option is a client option.
 type Option func(*client)
 
-func WithCache(ttl time.Duration) Option {
+// WithFollowReferrals makes the client follow referrals to other WHOIS servers,
+// e.g. from a thin registry (Verisign) to the registrar holding the contact data.
+// At most maxHops referrals are followed and the responses of all queried servers
+// are returned in the order they were queried.
+func WithFollowReferrals(maxHops int) Option {
 	return func(c *client) {
-		cache, err := ristretto.NewCache(&ristretto.Config[string, string]{
-			NumCounters: 1e7,     // number of keys to track frequency of (10M).
-			MaxCost:     1 << 30, // maximum cost of cache (1GB).
-			BufferItems: 64,      // number of keys per Get buffer.
-		})
-		if err != nil {
-			panic(err)
-		}
-
-		c.Cache.TTL = ttl
-		c.Cache.Storage = cache
+		c.MaxHops = maxHops
 	}
 }
 
@@ -68,7 +91,12 @@ func New(opts ...Option) (Client, error) {
 
 func newClient(opts ...Option) (*client, error) {
 	client := &client{
-		TLDs: make(map[string]adapter.Adapter),
+		TLDs:       make(map[string]adapter.Adapter),
+		Alternates: make(map[adapter.Adapter][]adapter.Adapter),
+		Charsets:   make(map[adapter.Adapter]string),
+		IANA:       DefaultIANAServer,
+		Bootstrap:  rdap.NewBootstrap(),
+		Transport:  &adapter.Transport{},
 	}
 
 	for _, opt := range opts {
@@ -82,49 +110,295 @@ func newClient(opts ...Option) (*client, error) {
 	return client, nil
 }
 
-func (c *client) whois(ctx context.Context, host string, servers ...string) (string, error) {
-	v, err, _ := c.SF.Do(host, func() (interface{}, error) {
-		if len(servers) == 0 {
-			ad, err := c.guess(host)
-			if err != nil {
-				return "", err
-			}
-			return ad.Get(ctx, host)
+// LookupOption is a per-lookup option.
+type LookupOption func(*lookupOptions)
+
+// lookupOptions are the settings of a single lookup.
+// Every setting changing the result must be part of the key.
+type lookupOptions struct {
+	servers  []string
+	maxHops  int
+	protocol Protocol
+	original bool
+}
+
+// key returns the canonical key of the lookup of host, shared by the cache and the
+// in-flight lookups. Lookups with different results never share a key.
+func (o *lookupOptions) key(host string) string {
+	protocol, maxHops := o.protocol, o.maxHops
+	if len(o.servers) > 0 {
+		protocol = ProtocolWHOIS // explicit servers are always queried over WHOIS
+	}
+	if protocol == ProtocolRDAP {
+		maxHops = 0 // RDAP lookups do not follow referrals
+	}
+
+	servers := make([]string, len(o.servers))
+	for i, server := range o.servers {
+		servers[i] = strconv.Quote(strings.ToLower(server))
+	}
+
+	key := fmt.Sprintf("lookup:%q servers=[%s] protocol=%s hops=%d",
+		host, strings.Join(servers, ","), protocol, maxHops)
+	if o.original && protocol != ProtocolRDAP {
+		key += " original"
+	}
+	return key
+}
+
+// lookupOptions returns the settings of a lookup, the client defaults overridden by opts.
+func (c *client) lookupOptions(opts []LookupOption) lookupOptions {
+	o := lookupOptions{
+		maxHops:  c.MaxHops,
+		protocol: c.Protocol,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// normalize returns the query the lookup of host is routed and cached by, see Normalize.
+// Queries sent to explicit servers are only trimmed, since they may carry server specific flags
+// (e.g. "domain example.com" or "n + 8.8.8.8"), see validateServerQuery.
+func (o *lookupOptions) normalize(host string) (string, error) {
+	if len(o.servers) > 0 {
+		query := strings.TrimSpace(host)
+		return query, validateServerQuery(host, query)
+	}
+	return Normalize(host)
+}
+
+// LookupServers sets the WHOIS servers to try in order, instead of the guessed one.
+func LookupServers(servers ...string) LookupOption {
+	return func(o *lookupOptions) {
+		o.servers = servers
+	}
+}
+
+// LookupOriginal keeps the WHOIS responses as received in Response.Original (and Hop.Original)
+// when they had to be decoded to UTF-8, see Response.Charset.
+func LookupOriginal() LookupOption {
+	return func(o *lookupOptions) {
+		o.original = true
+	}
+}
+
+// LookupFollowReferrals overrides the number of referrals to follow for a lookup,
+// see WithFollowReferrals.
+func LookupFollowReferrals(maxHops int) LookupOption {
+	return func(o *lookupOptions) {
+		o.maxHops = maxHops
+	}
+}
+
+func (c *client) lookup(ctx context.Context, host string, o lookupOptions) (*Response, error) {
+	v, err, _ := c.SF.Do(o.key(host), func() (interface{}, error) {
+		resp, err := c.fetch(ctx, host, o)
+		if err != nil && callerExpired(ctx) {
+			// The flight ran out of time, the callers sharing it must not cache the failure, see cacheTTL.
+			err = errors.Mark(err, errLookupExpired)
 		}
+		return resp, err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*Response), nil
+}
+
+// fetch looks the host up with the protocol strategy of the options.
+func (c *client) fetch(ctx context.Context, host string, o lookupOptions) (*Response, error) {
+	if len(o.servers) > 0 {
+		return c.whois(ctx, host, o)
+	}
+
+	switch o.protocol {
+	case ProtocolRDAP:
+		return c.rdap(ctx, host)
 
-		for _, server := range servers {
-			result, err := adapter.Standart(server, nil).Get(ctx, host)
+	case ProtocolRDAPFallback:
+		resp, err := c.rdap(ctx, host)
+		if err == nil || errors.Is(err, ErrObjectNotFound) {
+			return resp, err
+		}
+		slog.DebugContext(ctx, "RDAP lookup failed, falling back to WHOIS", "host", host, "err", err)
+		return c.whois(ctx, host, o)
+
+	case ProtocolWHOISFallback:
+		resp, err := c.whois(ctx, host, o)
+		if err == nil {
+			return resp, nil
+		}
+		slog.DebugContext(ctx, "WHOIS lookup failed, falling back to RDAP", "host", host, "err", err)
+		if resp, rerr := c.rdap(ctx, host); rerr == nil {
+			return resp, nil
+		}
+		return nil, err
+	}
+	return c.whois(ctx, host, o)
+}
+
+// whois performs a port 43 WHOIS lookup, using the explicit servers if any.
+// The servers are tried in order (the guessed one first, then its alternates),
+// each of them as many times as the retry policy allows.
+func (c *client) whois(ctx context.Context, host string, o lookupOptions) (*Response, error) {
+	var (
+		servers []adapter.Adapter
+		query   = host
+	)
+	if len(o.servers) == 0 {
+		ad, q, err := c.route(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		servers = append([]adapter.Adapter{ad}, c.Alternates[ad]...)
+		query = q
+	} else {
+		for _, server := range o.servers {
+			servers = append(servers, adapter.Standart(server, nil, c.Transport))
+		}
+	}
+
+	var errs []error
+	for _, ad := range servers {
+		for attempt := 1; ; attempt++ {
+			resp, err := c.query(ctx, ad, host, query, o)
 			if err == nil {
-				return result, nil
+				return resp, nil
+			}
+			errs = append(errs, err)
+
+			if ctx.Err() != nil {
+				return nil, attemptsError(host, errs)
+			}
+			if attempt >= c.Retry.Attempts || !c.Retry.retryable(err) {
+				break
+			}
+			slog.DebugContext(ctx, "retrying WHOIS query", "query", query, "server", ad.Server(), "attempt", attempt, "err", err)
+			if err := c.Retry.wait(ctx, attempt); err != nil {
+				return nil, attemptsError(host, append(errs, err))
 			}
 		}
-		return "", errors.Errorf("%q: no WHOIS server responded", host)
-	})
+	}
+	return nil, attemptsError(host, errs)
+}
 
+// query sends the query to the adapter and follows the referrals of the response.
+func (c *client) query(ctx context.Context, ad adapter.Adapter, host, query string, o lookupOptions) (*Response, error) {
+	start := time.Now()
+	result, err := c.get(ctx, ad, query, o.original)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return v.(string), nil
+	resp := &Response{
+		Query:      host,
+		Normalized: query,
+		Protocol:   ProtocolWHOIS.String(),
+		Adapter:    ad.Name(),
+		Server:     ad.Server(),
+		Raw:        []byte(result.text),
+		Charset:    result.charset,
+		Original:   result.original,
+		FetchedAt:  start,
+	}
+	resp.Hops = c.follow(ctx, ad, query, result.text, o)
+	resp.Duration = time.Since(start)
+
+	return resp, nil
 }
 
-func (c *client) Whois(ctx context.Context, host string, servers ...string) (result string, err error) {
-	if c.Cache.Storage != nil {
-		if result, ok := c.Cache.Storage.Get(host); ok {
-			return result, nil
+// follow queries the WHOIS servers the response refers to, up to maxHops servers.
+// The referral of a forwarder is followed in any case, see adapter.Forwarder.
+// Referral loops end the chain, and so does a failing referral, since the responses
+// collected so far are still valid.
+func (c *client) follow(ctx context.Context, ad adapter.Adapter, query, response string, o lookupOptions) []Hop {
+	visited := map[string]bool{strings.ToLower(ad.Server()): true}
+
+	maxHops := o.maxHops
+	if _, ok := ad.(adapter.Forwarder); ok {
+		maxHops = max(maxHops, 1)
+	}
+
+	var hops []Hop
+	for range maxHops {
+		r, ok := ad.(adapter.Referrer)
+		if !ok {
+			break
+		}
+		server, ok := r.Referral(response)
+		if !ok || visited[server] {
+			break
+		}
+		visited[server] = true
+
+		start := time.Now()
+		ad = adapter.Standart(server, nil, c.Transport)
+		resp, err := c.get(ctx, ad, query, o.original)
+		if err != nil {
+			slog.DebugContext(ctx, "failed to follow referral", "query", query, "server", server, "err", err)
+			break
 		}
+		response = resp.text
+		hop := Hop{
+			Adapter:  ad.Name(),
+			Server:   ad.Server(),
+			Raw:      []byte(resp.text),
+			Charset:  resp.charset,
+			Original: resp.original,
+			Duration: time.Since(start),
+		}
+		hops = append(hops, hop)
 	}
+	return hops
+}
 
-	result, err = c.whois(ctx, host, servers...)
+func (c *client) Lookup(ctx context.Context, host string, opts ...LookupOption) (*Response, error) {
+	o := c.lookupOptions(opts)
+	query, err := o.normalize(host)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	key := o.key(query)
+
+	if c.Cache.Storage != nil {
+		if entry, ok := c.cached(ctx, key); ok {
+			if entry.stale() {
+				c.revalidate(ctx, key, query, o)
+			}
+			resp, err := entry.result()
+			return answer(host, resp, err)
+		}
 	}
 
+	resp, err := c.lookup(ctx, query, o)
 	if c.Cache.Storage != nil {
-		c.Cache.Storage.SetWithTTL(host, result, 0, c.Cache.TTL)
+		c.store(ctx, key, resp, err)
+	}
+	return answer(host, resp, err)
+}
+
+// answer returns the result of a lookup as a response to host, the query as given
+// by the caller. The response is copied, since it is shared by the lookups of
+// the same normalized query.
+func answer(host string, resp *Response, err error) (*Response, error) {
+	if err != nil {
+		return nil, err
 	}
+	r := *resp
+	r.Query = host
+	return &r, nil
+}
 
-	return result, nil
+func (c *client) Whois(ctx context.Context, host string, servers ...string) (result string, err error) {
+	resp, err := c.Lookup(ctx, host, LookupServers(servers...))
+	if err != nil {
+		return "", err
+	}
+	return resp.String(), nil
 }
 
 // matchesKnownDomain checks if the given host matches any known TLD patterns in the client's TLD map.
@@ -151,16 +425,78 @@ func (c *client) matchesKnownDomain(host string) adapter.Adapter {
 	return nil
 }
 
-func (c *client) guess(host string) (ad adapter.Adapter, err error) {
+// maxRewrites limits the number of times a query can be rewritten by adapters.
+const maxRewrites = 4
+
+// route finds the adapter responsible for the host and the query it should be sent.
+// Adapters implementing adapter.Rewriter translate the query, which is then routed again.
+func (c *client) route(ctx context.Context, host string) (adapter.Adapter, string, error) {
+	query := host
+	for range maxRewrites {
+		ad, q, err := c.guess(ctx, query)
+		if err != nil {
+			return nil, "", err
+		}
+
+		rw, ok := ad.(adapter.Rewriter)
+		if !ok {
+			return ad, q, nil
+		}
+
+		query, err = rw.Rewrite(q)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	return nil, "", &MalformedQueryError{Query: host, Reason: "too many query rewrites"}
+}
+
+// guess returns the adapter for the host together with the query to send,
+// which can differ from the host (e.g. AS numbers are sent in asplain notation).
+// Domains under a TLD missing from the data files are bootstrapped through IANA.
+func (c *client) guess(ctx context.Context, host string) (ad adapter.Adapter, query string, err error) {
 	if matchesTLD(host) {
-		return adapter.Standart("whois.iana.org", nil), nil
+		return adapter.Standart(c.IANA, nil, c.Transport), host, nil
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if ad = c.IPs.lookupAddr(addr); ad != nil {
+			return ad, host, nil
+		}
+		return nil, "", errors.Wrapf(ErrCannotMatchIP, "%q", host)
+	}
+
+	if prefix, err := netip.ParsePrefix(host); err == nil {
+		if ad = c.IPs.lookup(prefix.Masked()); ad != nil {
+			return ad, host, nil
+		}
+		return nil, "", errors.Wrapf(ErrCannotMatchIP, "%q", host)
+	}
+
+	if asn, ok := parseASN(host); ok {
+		if ad = c.ASNs.lookup(asn); ad != nil {
+			return ad, formatASN(asn), nil
+		}
+		// IANA knows the allocation of every AS number block, including
+		// the ones delegated after the data files were generated and reserved ones.
+		return adapter.Standart(c.IANA, nil, c.Transport), formatASN(asn), nil
 	}
 
 	if ad = c.matchesKnownDomain(host); ad != nil {
-		return ad, nil
+		return ad, host, nil
 	}
 
-	return nil, ErrCannotMatchTLD
+	if i := strings.LastIndexByte(host, '.'); i >= 0 && matchesTLD(host[i:]) {
+		ad, err = c.discover(ctx, host[i+1:])
+		if err != nil {
+			return nil, "", err
+		}
+		if ad != nil {
+			return ad, host, nil
+		}
+	}
+
+	return nil, "", errors.Wrapf(ErrCannotMatchTLD, "%q", host)
 }
 
 func (c *client) LoadData() error {
@@ -175,8 +511,25 @@ func (c *client) LoadData() error {
 		}
 
 		switch entry.Name() {
-		case "asn16.json", "asn32.json", "ipv4.json", "ipv6.json":
-			slog.Debug("unsupported data file", "file", entry.Name())
+		case "asn16.json", "asn32.json":
+			slog.Debug("loading ASN data file", "file", entry.Name())
+			data, err := data.Files.ReadFile(entry.Name())
+			if err != nil {
+				return errors.Wrap(err, "failed to read ASN data file")
+			}
+			if err := c.LoadDataASN(data); err != nil {
+				return errors.Wrap(err, "failed to load ASN data")
+			}
+
+		case "ipv4.json", "ipv6.json":
+			slog.Debug("loading IP data file", "file", entry.Name())
+			data, err := data.Files.ReadFile(entry.Name())
+			if err != nil {
+				return errors.Wrap(err, "failed to read IP data file")
+			}
+			if err := c.LoadDataIP(data); err != nil {
+				return errors.Wrap(err, "failed to load IP data")
+			}
 
 		case "tld.json":
 			slog.Debug("loading TLD data file", "file", entry.Name())
@@ -192,6 +545,15 @@ func (c *client) LoadData() error {
 			slog.Debug("skipping unknown data file", "file", entry.Name())
 		}
 	}
+
+	if err := c.LoadDataRDAP(data.RDAP, "rdap"); err != nil {
+		return errors.Wrap(err, "failed to load RDAP bootstrap data")
+	}
+	if c.BootstrapFiles != nil {
+		if err := c.LoadDataRDAP(c.BootstrapFiles, "."); err != nil {
+			return errors.Wrap(err, "failed to load RDAP bootstrap data")
+		}
+	}
 	return nil
 }
 
@@ -208,30 +570,106 @@ func (c *client) LoadDataTLD(data []byte) error {
 			continue
 		}
 
-		var options adapter.Options
-		err := json.Unmarshal([]byte(config.Raw), &options)
+		ad, err := c.newAdapter(config)
 		if err != nil {
-			return errors.Wrapf(err, "%q: failed to unmarshal adapter options", tld)
+			return errors.Wrapf(err, "%q", tld)
 		}
+		c.TLDs[tld] = ad
+	}
+	slog.Debug("TLD data loaded", "count", len(c.TLDs))
 
-		ad, err := adapter.Create(
-			config.Get("adapter").String(),
-			cmp.Or(config.Get("host").String(), config.Get("url").String()),
-			options,
-		)
+	return nil
+}
+
+// LoadDataIP loads IP allocation data where every key is an address block in CIDR notation.
+func (c *client) LoadDataIP(data []byte) error {
+	for key, config := range gjson.ParseBytes(data).Map() {
+		prefix, err := netip.ParsePrefix(key)
 		if err != nil {
-			return errors.Wrapf(err, "%q: failed to create adapter", tld)
+			return errors.Wrapf(err, "%q: failed to parse address block", key)
 		}
-		c.TLDs[tld] = ad
+
+		ad, err := c.newAdapter(config)
+		if err != nil {
+			return errors.Wrapf(err, "%q", key)
+		}
+		c.IPs.insert(prefix, ad)
 	}
-	slog.Debug("TLD data loaded", "count", len(c.TLDs))
+	slog.Debug("IP data loaded", "count", c.IPs.len())
+
+	return nil
+}
+
+// LoadDataASN loads Autonomous System Number allocation data where every key
+// is either a single number or a "first last" range.
+func (c *client) LoadDataASN(data []byte) error {
+	for key, config := range gjson.ParseBytes(data).Map() {
+		first, last, err := parseASNRange(key)
+		if err != nil {
+			return err
+		}
+
+		ad, err := c.newAdapter(config)
+		if err != nil {
+			return errors.Wrapf(err, "%q", key)
+		}
+		c.ASNs.insert(first, last, ad)
+	}
+	slog.Debug("ASN data loaded", "count", c.ASNs.len())
 
 	return nil
 }
 
+// newAdapter creates an adapter from a data file entry, together with the adapters
+// of its alternate servers ("alternates").
+func (c *client) newAdapter(config gjson.Result) (adapter.Adapter, error) {
+	// Options are the string values of the entry, lists such as "alternates" are not options.
+	options := make(adapter.Options)
+	config.ForEach(func(key, value gjson.Result) bool {
+		if value.Type == gjson.String {
+			options[key.String()] = value.String()
+		}
+		return true
+	})
+
+	name := config.Get("adapter").String()
+	server := cmp.Or(config.Get("host").String(), config.Get("url").String())
+	if name == "" && server == "" {
+		// Reserved entries (e.g. AS_TRANS) have neither an adapter nor a server.
+		name = "none"
+	}
+
+	charset := options["charset"]
+	if charset != "" {
+		if err := validateCharset(charset); err != nil {
+			return nil, err
+		}
+	}
+
+	ad, err := adapter.Create(name, server, options, c.Transport)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create adapter")
+	}
+	if charset != "" {
+		c.Charsets[ad] = charset
+	}
+
+	for _, alternate := range config.Get("alternates").Array() {
+		alt, err := adapter.Create(name, alternate.String(), options, c.Transport)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%q: failed to create alternate adapter", alternate.String())
+		}
+		c.Alternates[ad] = append(c.Alternates[ad], alt)
+		if charset != "" {
+			c.Charsets[alt] = charset
+		}
+	}
+	return ad, nil
+}
+
 func (c *client) Close() error {
-	if c.Cache.Storage != nil {
-		c.Cache.Storage.Close()
+	if closer, ok := c.Cache.Storage.(io.Closer); ok {
+		return closer.Close()
 	}
 	return nil
 }
@@ -1,11 +1,79 @@
 package whois
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// serve starts a local WHOIS server answering every query with the handler result.
+// It returns the server address in the "host:port" form.
+func serve(t *testing.T, handler func(query string) string) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				query, err := bufio.NewReader(conn).ReadString('\n')
+				if err != nil {
+					return
+				}
+				_, _ = io.WriteString(conn, handler(strings.TrimRight(query, "\r\n")))
+			}()
+		}
+	}()
+
+	return l.Addr().String()
+}
+
+// serveSilent starts a local WHOIS server accepting connections but never answering,
+// they are closed when the test ends. It returns the server address in the "host:port" form.
+func serveSilent(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	done := make(chan struct{})
+	t.Cleanup(func() {
+		close(done)
+		l.Close()
+	})
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				<-done
+				conn.Close()
+			}()
+		}
+	}()
+
+	return l.Addr().String()
+}
+
 func TestClientMatchDomains(t *testing.T) {
 	t.Parallel()
 
@@ -17,3 +85,414 @@ func TestClientMatchDomains(t *testing.T) {
 		require.NotNil(t, client.matchesKnownDomain(domain))
 	}
 }
+
+func TestClientMatchIPs(t *testing.T) {
+	t.Parallel()
+
+	client, err := newClient()
+	require.NoError(t, err)
+	require.NotNil(t, client)
+
+	tests := []struct {
+		ip      string
+		server  string
+		adapter string
+	}{
+		{ip: "8.8.8.8", server: "whois.arin.net", adapter: "arin"},
+		{ip: "1.1.1.1", server: "whois.apnic.net", adapter: "standart"},
+		{ip: "59.1.2.3", server: "whois.nic.or.kr", adapter: "standart"},
+		{ip: "58.1.2.3", server: "whois.apnic.net", adapter: "standart"},
+		{ip: "61.112.0.1", server: "whois.nic.ad.jp", adapter: "formatted"},
+		{ip: "192.72.253.1", server: "whois.arin.net", adapter: "arin"},
+		{ip: "192.72.1.1", server: "whois.apnic.net", adapter: "standart"},
+		{ip: "0.1.2.3", server: "", adapter: "none"},
+		{ip: "::ffff:1.1.1.1", server: "whois.apnic.net", adapter: "standart"},
+		{ip: "2001:4860:4860::8888", server: "whois.arin.net", adapter: "standart"},
+		{ip: "2001:4860:4860:0000:0000:0000:0000:8888", server: "whois.arin.net", adapter: "standart"},
+		{ip: "2a00:1450:4001:82a::200e", server: "whois.ripe.net", adapter: "standart"},
+		{ip: "2001::1", server: "", adapter: "not_implemented"},
+		{ip: "2002:808:808::1", server: "", adapter: "not_implemented"},
+	}
+
+	for _, tt := range tests {
+		ad, _, err := client.guess(context.Background(), tt.ip)
+		require.NoError(t, err, tt.ip)
+		require.Equal(t, tt.server, ad.Server(), tt.ip)
+		require.Equal(t, tt.adapter, ad.Name(), tt.ip)
+	}
+}
+
+func TestClientRouteTransitionAddresses(t *testing.T) {
+	t.Parallel()
+
+	client, err := newClient()
+	require.NoError(t, err)
+
+	ad, query, err := client.route(context.Background(), "2002:0808:0404::1")
+	require.NoError(t, err)
+	require.Equal(t, "8.8.4.4", query)
+	require.Equal(t, "whois.arin.net", ad.Server())
+
+	_, _, err = client.route(context.Background(), "2001:0:4136:e378:8000:63bf:3fff:fdd2")
+	require.ErrorIs(t, err, ErrNotImplemented)
+
+	var nie *NotImplementedError
+	require.ErrorAs(t, err, &nie)
+	require.Equal(t, "teredo", nie.Kind)
+	require.Contains(t, nie.Reason, "192.0.2.45")
+}
+
+func TestClientMatchASNs(t *testing.T) {
+	t.Parallel()
+
+	client, err := newClient()
+	require.NoError(t, err)
+
+	tests := []struct {
+		asn    string
+		query  string
+		server string
+	}{
+		{asn: "AS15169", query: "AS15169", server: "whois.arin.net"},
+		{asn: "as3333", query: "AS3333", server: "whois.ripe.net"},
+		{asn: "7", query: "AS7", server: "whois.ripe.net"},
+		{asn: "AS6", query: "AS6", server: "whois.arin.net"},
+		{asn: "as131072", query: "AS131072", server: "whois.apnic.net"},
+		{asn: "AS2.0", query: "AS131072", server: "whois.apnic.net"},
+		{asn: "6.1", query: "AS393217", server: "whois.arin.net"},
+		{asn: "as4200000000", query: "AS4200000000", server: "whois.iana.org"},
+		{asn: "AS23456", query: "AS23456", server: ""},
+	}
+
+	for _, tt := range tests {
+		ad, query, err := client.guess(context.Background(), tt.asn)
+		require.NoError(t, err, tt.asn)
+		require.Equal(t, tt.query, query, tt.asn)
+		require.Equal(t, tt.server, ad.Server(), tt.asn)
+	}
+}
+
+func Test_parseASN(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		want uint32
+		ok   bool
+	}{
+		{in: "AS15169", want: 15169, ok: true},
+		{in: "as4200000000", want: 4200000000, ok: true},
+		{in: "1.10", want: 65546, ok: true},
+		{in: "AS65535.65535", want: 4294967295, ok: true},
+		{in: "AS4294967296", ok: false},
+		{in: "AS65536.1", ok: false},
+		{in: "AS", ok: false},
+		{in: "example.com", ok: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseASN(tt.in)
+		require.Equal(t, tt.ok, ok, tt.in)
+		require.Equal(t, tt.want, got, tt.in)
+	}
+}
+
+func TestClientRouteReverseNames(t *testing.T) {
+	t.Parallel()
+
+	client, err := newClient()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		query  string
+		server string
+	}{
+		{name: "4.4.8.8.in-addr.arpa", query: "8.8.4.4", server: "whois.arin.net"},
+		{name: "1.1.in-addr.arpa", query: "1.1.0.0/16", server: "whois.apnic.net"},
+		{name: "2.in-addr.arpa", query: "2.0.0.0/8", server: "whois.ripe.net"},
+		{name: "128/26.2.0.192.in-addr.arpa", query: "192.0.2.128/26", server: "whois.arin.net"},
+		{
+			name:   "8.8.8.8.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.6.8.4.1.0.0.2.ip6.arpa",
+			query:  "2001:4860::8888",
+			server: "whois.arin.net",
+		},
+		{name: "0.6.8.4.1.0.0.2.ip6.arpa", query: "2001:4860::/32", server: "whois.arin.net"},
+		{name: "0.0.a.2.ip6.arpa", query: "2a00::/16", server: "whois.ripe.net"},
+	}
+
+	for _, tt := range tests {
+		ad, query, err := client.route(context.Background(), tt.name)
+		require.NoError(t, err, tt.name)
+		require.Equal(t, tt.query, query, tt.name)
+		require.Equal(t, tt.server, ad.Server(), tt.name)
+	}
+
+	for _, name := range []string{"in-addr.arpa", "256.in-addr.arpa", "1.2.3.4.5.in-addr.arpa", "zz.ip6.arpa"} {
+		_, _, err := client.route(context.Background(), name)
+		require.Error(t, err, name)
+	}
+}
+
+func TestClientFollowReferrals(t *testing.T) {
+	t.Parallel()
+
+	var registrarQueries atomic.Int32
+	var registrar string
+	registrar = serve(t, func(query string) string {
+		registrarQueries.Add(1)
+		return "Registrant Name: Example\nRegistrar WHOIS Server: " + registrar + "\n"
+	})
+	registry := serve(t, func(query string) string {
+		return "Domain Name: EXAMPLE.COM\nRegistrar WHOIS Server: " + registrar + "\n"
+	})
+
+	client, err := newClient()
+	require.NoError(t, err)
+
+	result, err := client.Whois(context.Background(), "example.com", registry)
+	require.NoError(t, err)
+	require.Equal(t, "Domain Name: EXAMPLE.COM\nRegistrar WHOIS Server: "+registrar+"\n", result)
+	require.Zero(t, registrarQueries.Load())
+
+	client, err = newClient(WithFollowReferrals(5))
+	require.NoError(t, err)
+
+	result, err = client.Whois(context.Background(), "example.com", registry)
+	require.NoError(t, err)
+	require.Contains(t, result, "Domain Name: EXAMPLE.COM\n")
+	require.Contains(t, result, "Registrant Name: Example\n")
+	require.EqualValues(t, 1, registrarQueries.Load(), "referral loop must be detected")
+}
+
+func TestClientDiscoverTLD(t *testing.T) {
+	t.Parallel()
+
+	var ianaQueries atomic.Int32
+	registry := serve(t, func(query string) string {
+		return "Domain Name: " + query + "\n"
+	})
+	iana := serve(t, func(query string) string {
+		ianaQueries.Add(1)
+		switch query {
+		case "newtld":
+			return "domain:       NEWTLD\n\nwhois:        " + registry + "\n"
+		case "nowhois":
+			return "domain:       NOWHOIS\n\nstatus:       ACTIVE\n"
+		}
+		return "% This query returned 0 objects.\n"
+	})
+
+	client, err := newClient()
+	require.NoError(t, err)
+	client.IANA = iana
+
+	for range 2 {
+		result, err := client.Whois(context.Background(), "example.newtld")
+		require.NoError(t, err)
+		require.Equal(t, "Domain Name: example.newtld\n", result)
+	}
+	require.EqualValues(t, 1, ianaQueries.Load(), "discovered TLD must be memoized")
+
+	ad, _, err := client.guess(context.Background(), "example.nowhois")
+	require.NoError(t, err)
+	require.Equal(t, "none", ad.Name())
+
+	_, _, err = client.guess(context.Background(), "example.unknown")
+	require.ErrorIs(t, err, ErrCannotMatchTLD)
+	_, _, err = client.guess(context.Background(), "other.unknown")
+	require.ErrorIs(t, err, ErrCannotMatchTLD)
+	require.EqualValues(t, 3, ianaQueries.Load(), "unknown TLD must be remembered")
+	_, ok := client.Discovered.Load("unknown")
+	require.False(t, ok, "unknown TLDs must not be memoized for the life of the client")
+}
+
+func Test_unknownTLDs(t *testing.T) {
+	t.Parallel()
+
+	var u unknownTLDs
+	require.False(t, u.contains("unknown"))
+	u.add("unknown")
+	require.True(t, u.contains("unknown"))
+
+	u.expires["unknown"] = time.Now().Add(-time.Second)
+	require.False(t, u.contains("unknown"), "expired TLDs must be forgotten")
+	require.NotContains(t, u.expires, "unknown")
+
+	for i := range maxUnknownTLDs + 10 {
+		u.add(fmt.Sprintf("unknown%d", i))
+	}
+	require.Len(t, u.expires, maxUnknownTLDs)
+	require.True(t, u.contains(fmt.Sprintf("unknown%d", maxUnknownTLDs+9)))
+}
+
+func TestClientLookup(t *testing.T) {
+	t.Parallel()
+
+	var registrar string
+	registrar = serve(t, func(query string) string {
+		return "Registrant Name: Example\n"
+	})
+	registry := serve(t, func(query string) string {
+		return "Domain Name: EXAMPLE.COM\nRegistrar WHOIS Server: " + registrar + "\n"
+	})
+
+	client, err := newClient(withSyncCache(t, time.Minute))
+	require.NoError(t, err)
+	defer client.Close()
+
+	resp, err := client.Lookup(context.Background(), "example.com", LookupServers(registry), LookupFollowReferrals(1))
+	require.NoError(t, err)
+	require.Equal(t, "example.com", resp.Query)
+	require.Equal(t, "example.com", resp.Normalized)
+	require.Equal(t, "standart", resp.Adapter)
+	require.Equal(t, registry, resp.Server)
+	require.Equal(t, "Domain Name: EXAMPLE.COM\nRegistrar WHOIS Server: "+registrar+"\n", string(resp.Raw))
+	require.False(t, resp.FetchedAt.IsZero())
+	require.Positive(t, resp.Duration)
+	require.False(t, resp.Cached)
+	require.Len(t, resp.Hops, 1)
+	require.Equal(t, registrar, resp.Hops[0].Server)
+	require.Equal(t, "Registrant Name: Example\n", string(resp.Hops[0].Raw))
+
+	data, err := json.Marshal(resp)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"raw":"Domain Name: EXAMPLE.COM\n`)
+
+	var decoded Response
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, resp.Raw, decoded.Raw)
+	require.Equal(t, resp.Hops, decoded.Hops)
+	require.True(t, resp.FetchedAt.Equal(decoded.FetchedAt))
+
+	cached, err := client.Lookup(context.Background(), "example.com", LookupServers(registry), LookupFollowReferrals(1))
+	require.NoError(t, err)
+	require.True(t, cached.Cached)
+	require.Equal(t, resp.String(), cached.String())
+}
+
+func TestClientLookupKeys(t *testing.T) {
+	t.Parallel()
+
+	var registryQueries atomic.Int32
+	release := make(chan struct{})
+	registry := serve(t, func(query string) string {
+		registryQueries.Add(1)
+		<-release
+		return "Domain Name: " + query + "\nRegistrar WHOIS Server: whois.registrar.example\n"
+	})
+	registrar := serve(t, func(query string) string {
+		return "Domain Name: " + query + "\nRegistrant Name: Example\n"
+	})
+
+	client, err := newClient(withSyncCache(t, time.Hour))
+	require.NoError(t, err)
+	defer client.Close()
+	require.NoError(t, client.LoadDataTLD([]byte(`{"test": {"host": "`+registry+`"}}`)))
+
+	// A lookup in flight is not shared with a lookup of other servers.
+	done := make(chan *Response)
+	go func() {
+		resp, err := client.Lookup(context.Background(), "example.test")
+		assert.NoError(t, err)
+		done <- resp
+	}()
+	require.Eventually(t, func() bool { return registryQueries.Load() == 1 }, time.Second, time.Millisecond)
+
+	resp, err := client.Lookup(context.Background(), "example.test", LookupServers(registrar))
+	require.NoError(t, err)
+	require.Equal(t, registrar, resp.Server)
+	require.False(t, resp.Cached)
+
+	close(release)
+	resp = <-done
+	require.Equal(t, registry, resp.Server)
+
+	// Cached results are not shared between lookups with different settings either.
+	resp, err = client.Lookup(context.Background(), "example.test", LookupServers(registrar))
+	require.NoError(t, err)
+	require.True(t, resp.Cached)
+	require.Equal(t, registrar, resp.Server)
+
+	resp, err = client.Lookup(context.Background(), "example.test")
+	require.NoError(t, err)
+	require.True(t, resp.Cached)
+	require.Equal(t, registry, resp.Server)
+
+	resp, err = client.Lookup(context.Background(), "example.test", LookupFollowReferrals(1))
+	require.NoError(t, err)
+	require.False(t, resp.Cached)
+	require.EqualValues(t, 2, registryQueries.Load())
+}
+
+func Test_lookupOptionsKey(t *testing.T) {
+	t.Parallel()
+
+	keys := map[string]lookupOptions{}
+	for name, o := range map[string]lookupOptions{
+		"plain":          {},
+		"server":         {servers: []string{"whois.markmonitor.com"}},
+		"servers":        {servers: []string{"whois.markmonitor.com", "whois.verisign-grs.com"}},
+		"other order":    {servers: []string{"whois.verisign-grs.com", "whois.markmonitor.com"}},
+		"joined servers": {servers: []string{"whois.markmonitor.com,whois.verisign-grs.com"}},
+		"referrals":      {maxHops: 1},
+		"rdap":           {protocol: ProtocolRDAP},
+		"rdap fallback":  {protocol: ProtocolRDAPFallback},
+		"original":       {original: true},
+	} {
+		key := o.key("example.com")
+		other, ok := keys[key]
+		require.False(t, ok, "%s aliases %v", name, other)
+		keys[key] = o
+	}
+
+	// Settings that do not change the result share the key.
+	require.Equal(t,
+		(&lookupOptions{servers: []string{"whois.markmonitor.com"}}).key("example.com"),
+		(&lookupOptions{servers: []string{"WHOIS.MarkMonitor.com"}, protocol: ProtocolRDAP}).key("example.com"))
+	require.Equal(t,
+		(&lookupOptions{protocol: ProtocolRDAP}).key("example.com"),
+		(&lookupOptions{protocol: ProtocolRDAP, maxHops: 3}).key("example.com"))
+	require.NotEqual(t,
+		(&lookupOptions{}).key("example.com"),
+		(&lookupOptions{}).key("example.net"))
+}
+
+func TestClientArinReferral(t *testing.T) {
+	t.Parallel()
+
+	ripe := serve(t, func(query string) string {
+		return "inetnum: 192.0.2.0 - 192.0.2.255\nquery: " + query + "\n"
+	})
+	arinResponse := func(referral string) func(string) string {
+		return func(query string) string {
+			return "NetRange: 192.0.2.0 - 192.0.2.255\nquery: " + query + "\nReferralServer:  whois://" + referral + "\n"
+		}
+	}
+	arin := serve(t, arinResponse(ripe))
+
+	client, err := newClient()
+	require.NoError(t, err)
+	require.NoError(t, client.LoadDataIP([]byte(`{"192.0.2.0/24": {"adapter": "arin", "host": "`+arin+`"}}`)))
+
+	// The referral of ARIN is followed even without WithFollowReferrals.
+	resp, err := client.Lookup(context.Background(), "192.0.2.1")
+	require.NoError(t, err)
+	require.Equal(t, "arin", resp.Adapter)
+	require.Contains(t, string(resp.Raw), "query: n + 192.0.2.1\n")
+	require.Len(t, resp.Hops, 1)
+	require.Equal(t, ripe, resp.Hops[0].Server)
+	require.Equal(t, "inetnum: 192.0.2.0 - 192.0.2.255\nquery: 192.0.2.1\n", string(resp.Hops[0].Raw))
+
+	// A failing referral keeps the ARIN response.
+	dead := deadServer(t)
+	arin = serve(t, arinResponse(dead))
+	client, err = newClient()
+	require.NoError(t, err)
+	require.NoError(t, client.LoadDataIP([]byte(`{"192.0.2.0/24": {"adapter": "arin", "host": "`+arin+`"}}`)))
+
+	resp, err = client.Lookup(context.Background(), "192.0.2.1")
+	require.NoError(t, err)
+	require.Contains(t, string(resp.Raw), "query: n + 192.0.2.1\n")
+	require.Empty(t, resp.Hops)
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"log"
 	"log/slog"
@@ -76,11 +78,63 @@ const appHTML = `
 </html>
 `
 
+// errorStatus returns the HTTP status code for a lookup error.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, whois.ErrMalformedQuery),
+		errors.Is(err, whois.ErrCannotMatchTLD),
+		errors.Is(err, whois.ErrCannotMatchIP):
+		return fiber.StatusBadRequest
+	case errors.Is(err, whois.ErrObjectNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, whois.ErrRateLimited):
+		return fiber.StatusTooManyRequests
+	case errors.Is(err, whois.ErrNotImplemented),
+		errors.Is(err, whois.ErrNoWhoisServer),
+		errors.Is(err, whois.ErrNoRDAPService),
+		errors.Is(err, whois.ErrWebOnly):
+		return fiber.StatusNotImplemented
+	case errors.Is(err, whois.ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return fiber.StatusGatewayTimeout
+	case errors.Is(err, whois.ErrUnknownAvailability):
+		return fiber.StatusServiceUnavailable
+	case errors.Is(err, whois.ErrEmptyResponse),
+		errors.Is(err, whois.ErrResponseTooLarge):
+		return fiber.StatusBadGateway
+	}
+	return fiber.StatusInternalServerError
+}
+
+// sendError responds with the error message and the status code matching the error.
+// Web only registries get their URL in the Location header, rate limits a Retry-After header.
+func sendError(c fiber.Ctx, err error) error {
+	var webOnly *whois.WebOnlyError
+	if errors.As(err, &webOnly) {
+		c.Set(fiber.HeaderLocation, webOnly.URL)
+	}
+	var rateLimited *whois.RateLimitedError
+	if errors.As(err, &rateLimited) && rateLimited.RetryAfter > 0 {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(rateLimited.RetryAfter.Round(time.Second).Seconds())))
+	}
+	return c.Status(errorStatus(err)).SendString(err.Error())
+}
+
 func main() {
 	port := flag.Int("port", 8080, "port to listen on")
+	cacheDir := flag.String("cache-dir", "", "directory of the persistent cache, in memory if empty")
+	queryTimeout := flag.Duration("query-timeout", 15*time.Second, "maximum duration of a WHOIS query, 0 for no limit")
 	flag.Parse()
 
-	client, err := whois.New(whois.WithCache(time.Hour))
+	var storage []whois.Cache
+	if *cacheDir != "" {
+		cache, err := whois.NewDiskCache(*cacheDir)
+		if err != nil {
+			panic(err)
+		}
+		storage = append(storage, cache)
+	}
+
+	client, err := whois.New(whois.WithCache(time.Hour, storage...), whois.WithQueryTimeout(*queryTimeout))
 	if err != nil {
 		panic(err)
 	}
@@ -103,11 +157,44 @@ func main() {
 		result, err := client.Whois(c.Context(), data.Host)
 		slog.InfoContext(c.Context(), "whois request completed", "host", data.Host, "duration", time.Since(now), "err", err)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+			return sendError(c, err)
 		}
 
 		return c.SendString(result)
 	})
+	app.Post("/lookup", func(c fiber.Ctx) error {
+		var data struct {
+			Host string `json:"host"`
+		}
+		if err := c.Bind().Body(&data); err != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("Invalid request")
+		}
+		slog.InfoContext(c.Context(), "new lookup request", "host", data.Host)
+		resp, err := client.Lookup(c.Context(), data.Host)
+		if err != nil {
+			slog.InfoContext(c.Context(), "lookup request failed", "host", data.Host, "err", err)
+			return sendError(c, err)
+		}
+		slog.InfoContext(c.Context(), "lookup request completed", "host", data.Host,
+			"server", resp.Server, "duration", resp.Duration, "cached", resp.Cached)
+
+		return c.JSON(resp)
+	})
+	app.Post("/available", func(c fiber.Ctx) error {
+		var data struct {
+			Host string `json:"host"`
+		}
+		if err := c.Bind().Body(&data); err != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("Invalid request")
+		}
+		available, err := client.Available(c.Context(), data.Host)
+		slog.InfoContext(c.Context(), "availability request completed", "host", data.Host, "available", available, "err", err)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		return c.JSON(fiber.Map{"host": data.Host, "available": available})
+	})
 
 	log.Fatal(app.Listen(":" + strconv.Itoa(*port)))
 }
@@ -2,7 +2,13 @@ package whois
 
 import "regexp"
 
-var tldRex = regexp.MustCompile(`^\.(xn--)?[a-z0-9]+$`)
+var (
+	tldRex = regexp.MustCompile(`^\.(xn--)?[a-z0-9]+$`)
+	asnRex = regexp.MustCompile(`^(?i:as)?([0-9]+)(?:\.([0-9]+))?$`)
+
+	// handleRex matches a lowercased registry handle, e.g. "gogl-arin" or "net-8-8-8-0-1".
+	handleRex = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9_-]{0,78}[a-z0-9])?$`)
+)
 
 func matchesTLD(s string) bool {
 	return tldRex.MatchString(s)
@@ -0,0 +1,186 @@
+package whois
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// serveProxy starts a local proxy server, the handshake returns the address to connect to.
+// It returns the proxy address in the "host:port" form.
+func serveProxy(t *testing.T, handshake func(conn net.Conn, r *bufio.Reader) (string, error)) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				address, err := handshake(conn, r)
+				if err != nil {
+					return
+				}
+				upstream, err := net.Dial("tcp", address)
+				if err != nil {
+					return
+				}
+				defer upstream.Close()
+				go func() { _, _ = io.Copy(upstream, r) }()
+				_, _ = io.Copy(conn, upstream)
+			}()
+		}
+	}()
+
+	return l.Addr().String()
+}
+
+func TestHTTPProxyDialer(t *testing.T) {
+	t.Parallel()
+
+	server := serve(t, func(query string) string {
+		return "Domain Name: " + query + "\n"
+	})
+
+	var mu sync.Mutex
+	var requests []string
+	proxy := serveProxy(t, func(conn net.Conn, r *bufio.Reader) (string, error) {
+		req, err := http.ReadRequest(r)
+		if err != nil {
+			return "", err
+		}
+		mu.Lock()
+		requests = append(requests, req.Method+" "+req.Host+" "+req.Header.Get("Proxy-Authorization"))
+		mu.Unlock()
+		_, err = io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		return req.Host, err
+	})
+
+	d, err := HTTPProxyDialer("http://user:secret@"+proxy, nil)
+	require.NoError(t, err)
+
+	client, err := newClient(WithDialer(d))
+	require.NoError(t, err)
+
+	result, err := client.Whois(context.Background(), "example.com", server)
+	require.NoError(t, err)
+	require.Equal(t, "Domain Name: example.com\n", result)
+	require.Equal(t, []string{"CONNECT " + server + " Basic dXNlcjpzZWNyZXQ="}, requests)
+
+	_, err = HTTPProxyDialer("socks5://"+proxy, nil)
+	require.Error(t, err)
+}
+
+func TestSOCKS5Dialer(t *testing.T) {
+	t.Parallel()
+
+	server := serve(t, func(query string) string {
+		return "Domain Name: " + query + "\n"
+	})
+
+	// A minimal SOCKS5 server (RFC 1928) without authentication.
+	proxy := serveProxy(t, func(conn net.Conn, r *bufio.Reader) (string, error) {
+		greeting := make([]byte, 2)
+		if _, err := io.ReadFull(r, greeting); err != nil {
+			return "", err
+		}
+		if _, err := r.Discard(int(greeting[1])); err != nil {
+			return "", err
+		}
+		if _, err := conn.Write([]byte{5, 0}); err != nil {
+			return "", err
+		}
+
+		req := make([]byte, 4)
+		if _, err := io.ReadFull(r, req); err != nil {
+			return "", err
+		}
+		var host string
+		switch req[3] {
+		case 1: // IPv4
+			ip := make([]byte, 4)
+			if _, err := io.ReadFull(r, ip); err != nil {
+				return "", err
+			}
+			host = net.IP(ip).String()
+		case 3: // domain name
+			n, err := r.ReadByte()
+			if err != nil {
+				return "", err
+			}
+			name := make([]byte, n)
+			if _, err := io.ReadFull(r, name); err != nil {
+				return "", err
+			}
+			host = string(name)
+		}
+		port := make([]byte, 2)
+		if _, err := io.ReadFull(r, port); err != nil {
+			return "", err
+		}
+
+		_, err := conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+		return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))), err
+	})
+
+	d, err := SOCKS5Dialer("socks5://"+proxy, nil)
+	require.NoError(t, err)
+
+	client, err := newClient(WithDialer(d))
+	require.NoError(t, err)
+
+	result, err := client.Whois(context.Background(), "example.com", server)
+	require.NoError(t, err)
+	require.Equal(t, "Domain Name: example.com\n", result)
+}
+
+func TestSourceAddressDialer(t *testing.T) {
+	t.Parallel()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	sources := make(chan string, 4)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			sources <- conn.RemoteAddr().(*net.TCPAddr).IP.String()
+			conn.Close()
+		}
+	}()
+
+	d, err := SourceAddressDialer(netip.MustParseAddr("127.0.0.1"), netip.MustParseAddr("127.0.0.2"))
+	require.NoError(t, err)
+
+	var got []string
+	for range 4 {
+		conn, err := d.DialContext(context.Background(), "tcp", l.Addr().String())
+		require.NoError(t, err)
+		conn.Close()
+		got = append(got, <-sources)
+	}
+	require.Equal(t, []string{"127.0.0.1", "127.0.0.2", "127.0.0.1", "127.0.0.2"}, got)
+
+	_, err = SourceAddressDialer()
+	require.Error(t, err)
+}
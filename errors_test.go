@@ -0,0 +1,59 @@
+package whois
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientErrors(t *testing.T) {
+	t.Parallel()
+
+	silent := serveSilent(t)
+
+	client, err := newClient()
+	require.NoError(t, err)
+	require.NoError(t, client.LoadDataTLD([]byte(`{
+		"noserver": {"adapter": "none"},
+		"webonly": {"adapter": "web", "url": "https://whois.example/"},
+		"silent": {"host": "`+silent+`"}
+	}`)))
+
+	_, err = client.Whois(context.Background(), "example.noserver")
+	require.ErrorIs(t, err, ErrNoWhoisServer)
+	var noServer *NoServerError
+	require.ErrorAs(t, err, &noServer)
+	require.Equal(t, "example.noserver", noServer.Query)
+
+	_, err = client.Whois(context.Background(), "example.webonly")
+	require.ErrorIs(t, err, ErrWebOnly)
+	var webOnly *WebOnlyError
+	require.ErrorAs(t, err, &webOnly)
+	require.Equal(t, "https://whois.example/", webOnly.URL)
+
+	_, err = client.Whois(context.Background(), "256.in-addr.arpa")
+	require.ErrorIs(t, err, ErrMalformedQuery)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = client.Whois(ctx, "example.silent")
+	require.ErrorIs(t, err, ErrTimeout)
+	var timeout *TimeoutError
+	require.ErrorAs(t, err, &timeout)
+	require.Equal(t, "example.silent", timeout.Query)
+	require.Equal(t, "127.0.0.1", timeout.Server)
+
+	// Canceling the lookup hangs up on the server, whatever the read timeout.
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start := time.Now()
+	_, err = client.Whois(ctx, "example.silent")
+	require.ErrorIs(t, err, context.Canceled)
+	require.NotErrorIs(t, err, ErrTimeout)
+	require.Less(t, time.Since(start), time.Second)
+
+	_, err = client.Whois(context.Background(), "example.unknown-tld")
+	require.ErrorIs(t, err, ErrCannotMatchTLD)
+}
@@ -4,18 +4,100 @@ import (
 	"context"
 	"errors"
 	"io"
+	"iter"
+
+	"github.com/joy4eg/whois/internal/adapter"
 )
 
 var (
 	ErrCannotMatchTLD = errors.New("cannot match TLD")
+	ErrCannotMatchIP  = errors.New("cannot match IP address")
+	ErrNotImplemented = adapter.ErrNotImplemented
+
+	// ErrNoWhoisServer is returned when the registry does not run a WHOIS server, see NoServerError.
+	ErrNoWhoisServer = adapter.ErrNoServer
+
+	// ErrWebOnly is returned when the registry only has a web form, see WebOnlyError.
+	ErrWebOnly = adapter.ErrWebOnly
+
+	// ErrTimeout is returned when a WHOIS server does not answer in time, see TimeoutError.
+	ErrTimeout = adapter.ErrTimeout
+
+	// ErrMalformedQuery is returned for queries that cannot be sent to any server, see MalformedQueryError.
+	ErrMalformedQuery = adapter.ErrMalformedQuery
+
+	// ErrUnknownAvailability is returned when a response neither describes a registered
+	// domain nor reports it as not found, e.g. when the server is throttling queries.
+	ErrUnknownAvailability = errors.New("cannot determine domain availability")
+
+	// ErrNoRDAPService is returned when no RDAP service is known for a query.
+	ErrNoRDAPService = errors.New("no RDAP service")
+
+	// ErrObjectNotFound is returned when the RDAP service does not have the queried object,
+	// e.g. the domain is not registered.
+	ErrObjectNotFound = errors.New("object not found")
+
+	// ErrEmptyResponse is returned when a WHOIS server closes the connection without a response.
+	ErrEmptyResponse = errors.New("empty response")
+
+	// ErrRateLimited is returned when a query is throttled, see RateLimitedError.
+	ErrRateLimited = errors.New("rate limited")
+
+	// ErrResponseTooLarge is returned when a response exceeds the size limit, see ResponseTooLargeError.
+	ErrResponseTooLarge = adapter.ErrResponseTooLarge
+)
+
+// NotImplementedError is returned for queries in special purpose address space
+// (e.g. Teredo) that no WHOIS server is authoritative for.
+type NotImplementedError = adapter.NotImplementedError
+
+type (
+	// NoServerError is returned for queries whose registry does not run a WHOIS server.
+	NoServerError = adapter.NoServerError
+
+	// WebOnlyError is returned for queries whose registry only has a web form, the URL is attached.
+	WebOnlyError = adapter.WebOnlyError
+
+	// TimeoutError is returned when a WHOIS server does not answer the query in time,
+	// the part of the response received so far is attached.
+	TimeoutError = adapter.TimeoutError
+
+	// ResponseTooLargeError is returned when a response exceeds the size limit,
+	// the response truncated to the limit is attached. See WithMaxResponseBytes.
+	ResponseTooLargeError = adapter.ResponseTooLargeError
+
+	// MalformedQueryError is returned for queries that cannot be sent to any server,
+	// e.g. an invalid reverse DNS name.
+	MalformedQueryError = adapter.MalformedQueryError
 )
 
 // Client is a whois client.
 type Client interface {
 	// Whois returns the result of a whois query for the given host.
+	// The host can be a domain name, a TLD, an IP address or an AS number ("AS15169", "1.10").
 	// The servers parameter is a list of whois servers to try in order, or nil to use the default list.
-	// The result is the raw whois output.
+	// The result is the raw whois output (the JSON object when the client protocol is RDAP).
 	Whois(ctx context.Context, host string, servers ...string) (result string, err error)
 
+	// Lookup returns the result of a whois query for the given host together with
+	// the server and adapter that answered it, timing and the referrals followed.
+	Lookup(ctx context.Context, host string, opts ...LookupOption) (*Response, error)
+
+	// RDAP returns the result of an RDAP query for the given host, a domain name,
+	// an IP address (or block) or an AS number. Response.Raw is the JSON object,
+	// use ParseRDAP to parse it. It is a shortcut for Lookup with LookupProtocol(ProtocolRDAP).
+	RDAP(ctx context.Context, host string) (*Response, error)
+
+	// WhoisBatch looks the queries up concurrently, at most opts.Concurrency at once and
+	// opts.ServerConcurrency per WHOIS server, and yields the results as they complete
+	// together with the index of their query. Every query gets a result, those not looked up
+	// before the context is cancelled fail with the context error. Stopping the iteration
+	// cancels the outstanding lookups.
+	WhoisBatch(ctx context.Context, queries []string, opts BatchOptions) iter.Seq2[int, BatchResult]
+
+	// Available reports whether the domain is not registered.
+	// It returns ErrUnknownAvailability when the response is ambiguous.
+	Available(ctx context.Context, domain string) (bool, error)
+
 	io.Closer
 }
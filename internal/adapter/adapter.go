@@ -24,6 +24,30 @@ type (
 		Name() string
 	}
 
+	// Rewriter is implemented by adapters that do not query a server themselves,
+	// but translate the query into another one that has to be routed from scratch.
+	Rewriter interface {
+		// Rewrite returns the query that should be routed instead of host.
+		Rewrite(host string) (string, error)
+	}
+
+	// Referrer is implemented by adapters whose responses can refer to another
+	// WHOIS server holding more details (e.g. the registrar of a thin registry).
+	Referrer interface {
+		// Referral returns the WHOIS server the response refers to.
+		Referral(response string) (server string, ok bool)
+	}
+
+	// Forwarder is implemented by referrers whose responses are mere pointers to the
+	// authoritative server, e.g. ARIN for address space transferred to another registry.
+	// Their referral is followed even when the client does not follow referrals otherwise.
+	Forwarder interface {
+		Referrer
+
+		// Forwards marks the adapter as a forwarder.
+		Forwards()
+	}
+
 	// Options is a map of adapter options.
 	Options map[string]string
 )
@@ -32,29 +56,34 @@ type (
 // It returns an implementation of the Adapter interface configured for the requested service.
 //
 // Parameters:
-//   - name: The type of adapter to create ("", "afilias", "arpa", etc)
+//   - name: The type of adapter to create ("", "afilias", "arin", "arpa", etc)
 //   - server: The WHOIS server address to connect to
 //   - options: Additional configuration options for the adapter
+//   - transport: The network settings used to reach the server, nil for the defaults
 //
 // Returns:
 //   - Adapter: The configured WHOIS adapter implementation
 //   - error: ErrNotFound if the requested adapter type is not supported
-func Create(name string, server string, options Options) (Adapter, error) {
+func Create(name string, server string, options Options, transport *Transport) (Adapter, error) {
 	switch name {
 	case "":
-		return Standart(server, options), nil
+		return Standart(server, options, transport), nil
 	case "afilias":
-		return Afilias(server, options)
+		return Afilias(server, options, transport)
+	case "arin":
+		return Arin(server, options, transport)
 	case "arpa":
-		return Arpa(server, options)
+		return Arpa(server, options, transport)
 	case "none":
-		return None(server, options)
+		return None(server, options, transport)
+	case "not_implemented":
+		return NotImplemented(server, options, transport)
 	case "formatted":
-		return Formatted(server, options)
+		return Formatted(server, options, transport)
 	case "verisign":
-		return Verisign(server, options)
+		return Verisign(server, options, transport)
 	case "web":
-		return Web(server, options)
+		return Web(server, options, transport)
 	}
 	return nil, errors.Wrap(ErrNotFound, name)
 }
@@ -5,11 +5,17 @@ import (
 )
 
 type afiliasAdapter struct {
-	server string
+	server    string
+	transport *Transport
 }
 
 func (a *afiliasAdapter) Get(ctx context.Context, host string) (string, error) {
-	return Request(ctx, host, a.server, 0)
+	return a.transport.Request(ctx, host, a.server, 0)
+}
+
+// Referral returns the registrar WHOIS server from the response.
+func (*afiliasAdapter) Referral(response string) (string, bool) {
+	return registrarReferral(response)
 }
 
 func (a *afiliasAdapter) Server() string {
@@ -20,8 +26,9 @@ func (*afiliasAdapter) Name() string {
 	return "afilias"
 }
 
-func Afilias(server string, _ Options) (Adapter, error) {
+func Afilias(server string, _ Options, transport *Transport) (Adapter, error) {
 	return &afiliasAdapter{
-		server: server,
+		server:    server,
+		transport: transport,
 	}, nil
 }
@@ -0,0 +1,67 @@
+package adapter
+
+import (
+	"context"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// arinReferralRex matches the referral to the authoritative WHOIS server ARIN includes
+// for address space transferred to other registries, e.g. "ReferralServer:  whois://whois.ripe.net".
+var arinReferralRex = regexp.MustCompile(`(?m)^ReferralServer:\s*whois://([^\s:/]+)(?::(\d+))?`)
+
+type arinAdapter struct {
+	server    string
+	transport *Transport
+}
+
+// Get queries ARIN for network records ("n + <query>").
+// The referral to the authoritative WHOIS server, if any, is followed by the client, see Referral.
+func (a *arinAdapter) Get(ctx context.Context, host string) (string, error) {
+	return a.transport.Request(ctx, "n + "+host, a.server, 0)
+}
+
+// Referral returns the authoritative WHOIS server of address space transferred
+// to another registry, in the "host:port" form when ARIN states the port.
+func (a *arinAdapter) Referral(response string) (string, bool) {
+	server, port, ok := arinReferral(response)
+	if !ok || strings.EqualFold(server, a.server) {
+		return "", false
+	}
+	if port != 0 {
+		server = net.JoinHostPort(server, strconv.Itoa(port))
+	}
+	return server, true
+}
+
+// Forwards marks ARIN as a forwarder, see Forwarder.
+func (*arinAdapter) Forwards() {}
+
+func (a *arinAdapter) Server() string {
+	return a.server
+}
+
+func (*arinAdapter) Name() string {
+	return "arin"
+}
+
+func Arin(server string, _ Options, transport *Transport) (Adapter, error) {
+	return &arinAdapter{
+		server:    server,
+		transport: transport,
+	}, nil
+}
+
+// arinReferral extracts the referral server and port from an ARIN response.
+func arinReferral(response string) (server string, port int, ok bool) {
+	m := arinReferralRex.FindStringSubmatch(response)
+	if m == nil {
+		return "", 0, false
+	}
+	if m[2] != "" {
+		port, _ = strconv.Atoi(m[2])
+	}
+	return m[1], port, true
+}
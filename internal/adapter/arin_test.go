@@ -0,0 +1,59 @@
+package adapter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArinReferral(t *testing.T) {
+	t.Parallel()
+
+	arin := serve(t, func(query string) string {
+		return "NetRange: 2.0.0.0 - 2.255.255.255\nquery: " + query + "\nReferralServer:  whois://whois.ripe.net:4343\n"
+	})
+
+	ad, err := Arin(arin, nil, nil)
+	require.NoError(t, err)
+
+	got, err := ad.Get(context.Background(), "2.2.2.2")
+	require.NoError(t, err)
+	require.Equal(t, "NetRange: 2.0.0.0 - 2.255.255.255\nquery: n + 2.2.2.2\nReferralServer:  whois://whois.ripe.net:4343\n", got)
+
+	require.Implements(t, (*Forwarder)(nil), ad)
+	server, ok := ad.(Referrer).Referral(got)
+	require.True(t, ok)
+	require.Equal(t, "whois.ripe.net:4343", server)
+
+	self, err := Arin("whois.ripe.net", nil, nil)
+	require.NoError(t, err)
+	_, ok = self.(Referrer).Referral(got)
+	require.False(t, ok, "referrals to the server itself must be ignored")
+}
+
+func Test_arinReferral(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		response string
+		server   string
+		port     int
+		ok       bool
+	}{
+		{name: "whois", response: "Foo: bar\nReferralServer:  whois://whois.ripe.net\n", server: "whois.ripe.net", ok: true},
+		{name: "whois with port", response: "ReferralServer: whois://whois.apnic.net:43\n", server: "whois.apnic.net", port: 43, ok: true},
+		{name: "rwhois", response: "ReferralServer:  rwhois://rwhois.example.net:4321\n", ok: false},
+		{name: "none", response: "NetRange: 8.0.0.0 - 8.255.255.255\n", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, port, ok := arinReferral(tt.response)
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.server, server)
+			require.Equal(t, tt.port, port)
+		})
+	}
+}
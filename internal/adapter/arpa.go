@@ -2,17 +2,57 @@ package adapter
 
 import (
 	"context"
+	"net/netip"
+	"strconv"
+	"strings"
 
 	"github.com/cockroachdb/errors"
 )
 
+const (
+	suffixIPv4 = ".in-addr.arpa"
+	suffixIPv6 = ".ip6.arpa"
+)
+
+// arpaAdapter translates reverse DNS names (in-addr.arpa, ip6.arpa) into the IP address
+// or address block they describe, so the owner of the block can be queried.
 type arpaAdapter struct{}
 
-func (*arpaAdapter) Get(ctx context.Context, host string) (string, error) {
-	return "", errors.Errorf("%q: not implemented", host)
+func (*arpaAdapter) Get(_ context.Context, host string) (string, error) {
+	return "", &NotImplementedError{
+		Query:  host,
+		Kind:   "arpa",
+		Reason: "reverse DNS names must be rewritten into an IP query",
+	}
 }
 
-func (a *arpaAdapter) Server() string {
+// Rewrite returns the IP address or the address block in CIDR notation for a reverse DNS name.
+func (*arpaAdapter) Rewrite(host string) (string, error) {
+	name := strings.TrimSuffix(strings.ToLower(host), ".")
+
+	var (
+		prefix netip.Prefix
+		err    error
+	)
+	switch {
+	case strings.HasSuffix(name, suffixIPv4):
+		prefix, err = parseReverseIPv4(strings.TrimSuffix(name, suffixIPv4))
+	case strings.HasSuffix(name, suffixIPv6):
+		prefix, err = parseReverseIPv6(strings.TrimSuffix(name, suffixIPv6))
+	default:
+		err = errors.New("unknown reverse DNS zone")
+	}
+	if err != nil {
+		return "", &MalformedQueryError{Query: host, Reason: "invalid reverse DNS name: " + err.Error()}
+	}
+
+	if prefix.IsSingleIP() {
+		return prefix.Addr().String(), nil
+	}
+	return prefix.String(), nil
+}
+
+func (*arpaAdapter) Server() string {
 	return ""
 }
 
@@ -20,6 +60,57 @@ func (*arpaAdapter) Name() string {
 	return "arpa"
 }
 
-func Arpa(string, Options) (Adapter, error) {
+func Arpa(string, Options, *Transport) (Adapter, error) {
 	return &arpaAdapter{}, nil
 }
+
+// parseReverseIPv4 parses the labels of an in-addr.arpa name ("4.4.8.8", "8.8").
+// Classless delegations (RFC 2317) such as "128/26.2.0.192" are supported as well.
+func parseReverseIPv4(s string) (netip.Prefix, error) {
+	labels := strings.Split(s, ".")
+	if s == "" || len(labels) > 4 {
+		return netip.Prefix{}, errors.New("expected 1 to 4 octets")
+	}
+
+	bits := len(labels) * 8
+	if first, length, ok := strings.Cut(labels[0], "/"); ok && len(labels) == 4 {
+		n, err := strconv.Atoi(length)
+		if err != nil || n < 24 || n > 32 {
+			return netip.Prefix{}, errors.Newf("invalid classless delegation %q", labels[0])
+		}
+		labels[0], bits = first, n
+	}
+
+	var b [4]byte
+	for i, label := range labels {
+		n, err := strconv.ParseUint(label, 10, 8)
+		if err != nil {
+			return netip.Prefix{}, errors.Newf("invalid octet %q", label)
+		}
+		b[len(labels)-1-i] = byte(n)
+	}
+	return netip.PrefixFrom(netip.AddrFrom4(b), bits).Masked(), nil
+}
+
+// parseReverseIPv6 parses the nibbles of an ip6.arpa name ("8.8.8.8.0.0.0.0...").
+func parseReverseIPv6(s string) (netip.Prefix, error) {
+	nibbles := strings.Split(s, ".")
+	if s == "" || len(nibbles) > 32 {
+		return netip.Prefix{}, errors.New("expected 1 to 32 nibbles")
+	}
+
+	var b [16]byte
+	for i, nibble := range nibbles {
+		n, err := strconv.ParseUint(nibble, 16, 4)
+		if err != nil || len(nibble) != 1 {
+			return netip.Prefix{}, errors.Newf("invalid nibble %q", nibble)
+		}
+		pos := len(nibbles) - 1 - i
+		if pos%2 == 0 {
+			b[pos/2] |= byte(n) << 4
+		} else {
+			b[pos/2] |= byte(n)
+		}
+	}
+	return netip.PrefixFrom(netip.AddrFrom16(b), len(nibbles)*4).Masked(), nil
+}
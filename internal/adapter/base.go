@@ -12,54 +12,120 @@ import (
 
 const DefaultWhoisPort = 43
 
+// Request performs a WHOIS protocol query to a specified host and port with the default transport.
+// See Transport.Request.
+func Request(ctx context.Context, query, host string, port int) (string, error) {
+	return (*Transport)(nil).Request(ctx, query, host, port)
+}
+
 // Request performs a WHOIS protocol query to a specified host and port.
 //
-// It establishes a TCP connection to the host:port, sends the query string followed by CRLF,
-// and reads the complete response. The connection uses Multipath TCP if available.
+// It establishes a TCP connection to the host:port through the transport dialer, sends the query
+// string followed by CRLF, and reads the complete response. The default dialer uses Multipath TCP if available.
+// Queries failing ValidateQuery are rejected before connecting. The response is bound in size
+// and time by the transport settings, see Transport.
 //
 // Parameters:
 //   - ctx: Context for controlling the request lifetime
 //   - query: The WHOIS query string to send
-//   - host: The WHOIS server hostname or IP address
-//   - port: The port number to connect to (defaults to DefaultWhoisPort if 0)
+//   - host: The WHOIS server hostname or IP address, optionally with a port ("host:port")
+//   - port: The port number to connect to (defaults to the host port or DefaultWhoisPort if 0)
 //
 // Returns:
 //   - string: The complete response from the WHOIS server
-//   - error: Any error encountered during the connection, write or read operations
-func Request(ctx context.Context, query, host string, port int) (string, error) {
-	var d net.Dialer
+//   - error: A MalformedQueryError for invalid queries, a ResponseTooLargeError or TimeoutError
+//     (both keeping the partial response), the context error once it is canceled, or any error
+//     encountered during the connection, write or read operations
+func (t *Transport) Request(ctx context.Context, query, host string, port int) (string, error) {
+	if err := ValidateQuery(query); err != nil {
+		return "", err
+	}
 
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		host = h
+		if port == 0 {
+			port, _ = strconv.Atoi(p)
+		}
+	}
 	if port == 0 {
 		port = DefaultWhoisPort
 	}
 
-	d.SetMultipathTCP(true)
-	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if timeout := t.timeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	conn, err := t.dialer().DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
 	if err != nil {
-		return "", errors.Wrapf(err, "%q: dial failed", host)
+		return "", requestError(ctx, err, query, host, "dial failed", nil)
 	}
 	defer conn.Close()
 
+	// The idle reader keeps moving the read deadline, closing the connection
+	// is what stops the query once the context is canceled.
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return "", requestError(ctx, err, query, host, "failed to set deadline", nil)
+	}
+
 	_, err = conn.Write([]byte(query + "\r\n"))
 	if err != nil {
-		return "", errors.Wrapf(err, "%q: write failed", host)
+		return "", requestError(ctx, err, query, host, "write failed", nil)
 	}
 
-	var buf bytes.Buffer
-	_, err = io.Copy(&buf, conn)
+	var (
+		buf   bytes.Buffer
+		r     io.Reader = &idleReader{conn: conn, timeout: t.readTimeout(), deadline: deadline}
+		limit           = t.maxResponseBytes()
+	)
+	if limit > 0 {
+		// One byte more than allowed tells a response of the maximum size from a larger one.
+		r = io.LimitReader(r, limit+1)
+	}
+	_, err = io.Copy(&buf, r)
 	if err != nil {
-		return "", errors.Wrapf(err, "%q: read failed", host)
+		return "", requestError(ctx, err, query, host, "read failed", buf.Bytes())
+	}
+	if limit > 0 && int64(buf.Len()) > limit {
+		return "", &ResponseTooLargeError{Query: query, Server: host, Limit: limit, Partial: buf.Bytes()[:limit]}
 	}
 
 	return buf.String(), nil
 }
 
+// requestError wraps an error of the query sent to the host, timeouts become a TimeoutError
+// keeping the part of the response read so far. Once the context is done, its error
+// replaces the one of the closed connection.
+func requestError(ctx context.Context, err error, query, host, msg string, partial []byte) error {
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	err = errors.Wrapf(err, "%q: %s", host, msg)
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() || errors.Is(err, context.DeadlineExceeded) {
+		return &TimeoutError{Query: query, Server: host, Err: err, Partial: partial}
+	}
+	return err
+}
+
 type standartAdapter struct {
-	server string
+	server    string
+	transport *Transport
 }
 
 func (a *standartAdapter) Get(ctx context.Context, host string) (string, error) {
-	return Request(ctx, host, a.server, 0)
+	return a.transport.Request(ctx, host, a.server, 0)
+}
+
+// Referral returns the registrar WHOIS server from the response.
+func (*standartAdapter) Referral(response string) (string, bool) {
+	return registrarReferral(response)
 }
 
 func (a *standartAdapter) Server() string {
@@ -70,8 +136,9 @@ func (*standartAdapter) Name() string {
 	return "standart"
 }
 
-func Standart(server string, options Options) Adapter {
+func Standart(server string, options Options, transport *Transport) Adapter {
 	return &standartAdapter{
-		server: server,
+		server:    server,
+		transport: transport,
 	}
 }
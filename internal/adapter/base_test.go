@@ -1,7 +1,11 @@
 package adapter
 
 import (
+	"bufio"
 	"context"
+	"io"
+	"net"
+	"strings"
 	"testing"
 	"time"
 
@@ -51,3 +55,141 @@ func TestRequest(t *testing.T) {
 		})
 	}
 }
+
+// serve starts a local WHOIS server answering every query with the handler result.
+// It returns the server address in the "host:port" form.
+func serve(t testing.TB, handler func(query string) string) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				query, err := bufio.NewReader(conn).ReadString('\n')
+				if err != nil {
+					return
+				}
+				_, _ = io.WriteString(conn, handler(strings.TrimRight(query, "\r\n")))
+			}()
+		}
+	}()
+
+	return l.Addr().String()
+}
+
+// listen starts a local server handing every connection to the handler once the query is read.
+// It returns the server address in the "host:port" form.
+func listen(t *testing.T, handler func(conn net.Conn)) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				if _, err := bufio.NewReader(conn).ReadString('\n'); err != nil {
+					return
+				}
+				handler(conn)
+			}()
+		}
+	}()
+
+	return l.Addr().String()
+}
+
+func TestRequestLimits(t *testing.T) {
+	t.Parallel()
+
+	// endless streams data until the client hangs up.
+	endless := listen(t, func(conn net.Conn) {
+		line := []byte(strings.Repeat("x", 1023) + "\n")
+		for {
+			if _, err := conn.Write(line); err != nil {
+				return
+			}
+		}
+	})
+	// stalled sends the beginning of the response, then stays silent.
+	stalled := listen(t, func(conn net.Conn) {
+		_, _ = io.WriteString(conn, "Domain Name: EXAMPLE.COM\n")
+		time.Sleep(time.Second)
+	})
+	// trickling sends a line every few milliseconds for a second.
+	trickling := listen(t, func(conn net.Conn) {
+		for range 100 {
+			if _, err := io.WriteString(conn, "%\n"); err != nil {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+	exact := serve(t, func(query string) string {
+		return strings.Repeat("x", 64)
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		t.Parallel()
+		tr := &Transport{MaxResponseBytes: 64 << 10}
+		_, err := tr.Request(context.Background(), "example.com", endless, 0)
+		var tooLarge *ResponseTooLargeError
+		require.ErrorAs(t, err, &tooLarge)
+		require.ErrorIs(t, err, ErrResponseTooLarge)
+		require.Equal(t, "127.0.0.1", tooLarge.Server)
+		require.EqualValues(t, 64<<10, tooLarge.Limit)
+		require.Len(t, tooLarge.Partial, 64<<10)
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		t.Parallel()
+		got, err := (&Transport{MaxResponseBytes: 64}).Request(context.Background(), "example.com", exact, 0)
+		require.NoError(t, err)
+		require.Len(t, got, 64)
+	})
+
+	t.Run("read timeout", func(t *testing.T) {
+		t.Parallel()
+		_, err := (&Transport{ReadTimeout: 50 * time.Millisecond}).Request(context.Background(), "example.com", stalled, 0)
+		var timeout *TimeoutError
+		require.ErrorAs(t, err, &timeout)
+		require.Equal(t, "Domain Name: EXAMPLE.COM\n", string(timeout.Partial))
+	})
+
+	t.Run("trickling", func(t *testing.T) {
+		t.Parallel()
+		// The idle timeout alone does not stop a server sending a byte now and then.
+		start := time.Now()
+		_, err := (&Transport{ReadTimeout: 50 * time.Millisecond, Timeout: 200 * time.Millisecond}).Request(context.Background(), "example.com", trickling, 0)
+		var timeout *TimeoutError
+		require.ErrorAs(t, err, &timeout)
+		require.ErrorIs(t, err, ErrTimeout)
+		require.NotEmpty(t, timeout.Partial)
+		require.Less(t, time.Since(start), 900*time.Millisecond)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(100*time.Millisecond, cancel)
+		start := time.Now()
+		_, err := (&Transport{ReadTimeout: 50 * time.Millisecond}).Request(ctx, "example.com", trickling, 0)
+		require.ErrorIs(t, err, context.Canceled)
+		require.NotErrorIs(t, err, ErrTimeout)
+		require.Less(t, time.Since(start), 900*time.Millisecond)
+	})
+}
@@ -0,0 +1,120 @@
+package adapter
+
+import (
+	"fmt"
+
+	"github.com/cockroachdb/errors"
+)
+
+var (
+	// ErrNoServer is returned for queries that no WHOIS server is known for,
+	// e.g. a TLD whose registry does not run one.
+	ErrNoServer = errors.New("no WHOIS server")
+
+	// ErrWebOnly is returned when the registry only publishes its data through a web form.
+	ErrWebOnly = errors.New("WHOIS only available through a web form")
+
+	// ErrTimeout is returned when a WHOIS server does not answer in time.
+	ErrTimeout = errors.New("timeout")
+
+	// ErrMalformedQuery is returned for queries that cannot be sent to any server.
+	ErrMalformedQuery = errors.New("malformed query")
+
+	// ErrResponseTooLarge is returned when a response exceeds the size limit of the transport.
+	ErrResponseTooLarge = errors.New("response too large")
+)
+
+// NoServerError describes a query whose registry does not run a WHOIS server.
+type NoServerError struct {
+	// Query is the original query.
+	Query string
+}
+
+func (e *NoServerError) Error() string {
+	return fmt.Sprintf("%q: does not have a WHOIS server", e.Query)
+}
+
+// Is reports whether the target is ErrNoServer.
+func (e *NoServerError) Is(target error) bool {
+	return target == ErrNoServer
+}
+
+// WebOnlyError describes a query whose registry only has a web form.
+type WebOnlyError struct {
+	// Query is the original query.
+	Query string
+	// URL is the web form of the registry.
+	URL string
+}
+
+func (e *WebOnlyError) Error() string {
+	return fmt.Sprintf("%q: server does not support WHOIS protocol, try web interface %v", e.Query, e.URL)
+}
+
+// Is reports whether the target is ErrWebOnly.
+func (e *WebOnlyError) Is(target error) bool {
+	return target == ErrWebOnly
+}
+
+// TimeoutError describes a query the WHOIS server did not answer in time.
+type TimeoutError struct {
+	// Query is the query sent to the server.
+	Query string
+	// Server is the WHOIS server.
+	Server string
+	// Err is the underlying network error.
+	Err error
+	// Partial is the part of the response received before the timeout, if any.
+	Partial []byte
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("query %q timed out: %v", e.Query, e.Err)
+}
+
+// Is reports whether the target is ErrTimeout.
+func (e *TimeoutError) Is(target error) bool {
+	return target == ErrTimeout
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// ResponseTooLargeError describes a response exceeding the size limit of the transport.
+type ResponseTooLargeError struct {
+	// Query is the query sent to the server.
+	Query string
+	// Server is the WHOIS server, or the lookup URL of an RDAP service.
+	Server string
+	// Limit is the maximum size of a response in bytes.
+	Limit int64
+	// Partial is the response truncated to the limit.
+	Partial []byte
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("%q: response to query %q exceeds %d bytes", e.Server, e.Query, e.Limit)
+}
+
+// Is reports whether the target is ErrResponseTooLarge.
+func (e *ResponseTooLargeError) Is(target error) bool {
+	return target == ErrResponseTooLarge
+}
+
+// MalformedQueryError describes a query that cannot be sent to any server.
+type MalformedQueryError struct {
+	// Query is the original query.
+	Query string
+	// Reason explains what is wrong with the query.
+	Reason string
+}
+
+func (e *MalformedQueryError) Error() string {
+	return fmt.Sprintf("%q: malformed query: %s", e.Query, e.Reason)
+}
+
+// Is reports whether the target is ErrMalformedQuery.
+func (e *MalformedQueryError) Is(target error) bool {
+	return target == ErrMalformedQuery
+}
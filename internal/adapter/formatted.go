@@ -2,20 +2,18 @@ package adapter
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/cockroachdb/errors"
 )
 
 type formattedAdapter struct {
-	server string
-	format string
+	server    string
+	format    queryFormat
+	transport *Transport
 }
 
 func (a *formattedAdapter) Get(ctx context.Context, host string) (string, error) {
-	query := fmt.Sprintf(a.format, host)
-
-	return Request(ctx, query, a.server, 0)
+	return a.transport.Request(ctx, a.format.format(host), a.server, 0)
 }
 
 func (a *formattedAdapter) Server() string {
@@ -26,14 +24,19 @@ func (*formattedAdapter) Name() string {
 	return "formatted"
 }
 
-func Formatted(server string, options Options) (Adapter, error) {
+func Formatted(server string, options Options, transport *Transport) (Adapter, error) {
 	format, ok := options["format"]
 	if !ok || format == "" {
 		return nil, errors.Errorf("format option is required")
 	}
+	f, err := parseQueryFormat(format)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid format option")
+	}
 
 	return &formattedAdapter{
-		server: server,
-		format: format,
+		server:    server,
+		format:    f,
+		transport: transport,
 	}, nil
 }
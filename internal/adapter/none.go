@@ -1,15 +1,11 @@
 package adapter
 
-import (
-	"context"
-
-	"github.com/cockroachdb/errors"
-)
+import "context"
 
 type noneAdapter struct{}
 
 func (*noneAdapter) Get(_ context.Context, host string) (string, error) {
-	return "", errors.Errorf("%q: does not have a WHOIS server", host)
+	return "", &NoServerError{Query: host}
 }
 
 func (*noneAdapter) Name() string {
@@ -20,6 +16,6 @@ func (*noneAdapter) Server() string {
 	return ""
 }
 
-func None(string, Options) (Adapter, error) {
+func None(string, Options, *Transport) (Adapter, error) {
 	return &noneAdapter{}, nil
 }
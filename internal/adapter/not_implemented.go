@@ -0,0 +1,111 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+
+	"github.com/cockroachdb/errors"
+)
+
+// ErrNotImplemented is returned for queries that no WHOIS server can answer directly.
+var ErrNotImplemented = errors.New("not implemented")
+
+// NotImplementedError describes a query that falls into special purpose address space.
+type NotImplementedError struct {
+	// Query is the original query.
+	Query string
+	// Kind is the kind of the address space, e.g. "teredo" or "6to4".
+	Kind string
+	// Reason explains why the query cannot be answered.
+	Reason string
+}
+
+func (e *NotImplementedError) Error() string {
+	return fmt.Sprintf("%q: %s address is not implemented: %s", e.Query, e.Kind, e.Reason)
+}
+
+// Is reports whether the target is ErrNotImplemented.
+func (e *NotImplementedError) Is(target error) bool {
+	return target == ErrNotImplemented
+}
+
+var (
+	prefix6to4   = netip.MustParsePrefix("2002::/16")
+	prefixTeredo = netip.MustParsePrefix("2001::/32")
+)
+
+// notImplementedAdapter handles IPv6 transition address space (Teredo, 6to4),
+// where the owner of an address is determined by the IPv4 address embedded in it.
+type notImplementedAdapter struct {
+	kind string
+}
+
+func (a *notImplementedAdapter) Get(_ context.Context, host string) (string, error) {
+	return "", a.error(host)
+}
+
+// Rewrite unwraps the IPv4 address (or address block) embedded into a 6to4 address.
+// Other kinds of addresses cannot be rewritten.
+func (a *notImplementedAdapter) Rewrite(host string) (string, error) {
+	prefix, err := parsePrefix(host)
+	if err != nil || !prefix6to4.Contains(prefix.Addr()) || prefix.Bits() < 16 {
+		return "", a.error(host)
+	}
+
+	b := prefix.Addr().As16()
+	v4 := netip.AddrFrom4([4]byte{b[2], b[3], b[4], b[5]})
+	if prefix.Bits() >= 48 {
+		return v4.String(), nil
+	}
+	return netip.PrefixFrom(v4, prefix.Bits()-16).Masked().String(), nil
+}
+
+func (a *notImplementedAdapter) error(host string) error {
+	err := &NotImplementedError{
+		Query:  host,
+		Kind:   a.kind,
+		Reason: "address space is not registered with any RIR",
+	}
+
+	switch a.kind {
+	case "6to4":
+		err.Reason = "6to4 address (RFC 3056), query the embedded IPv4 address instead"
+	case "teredo":
+		err.Reason = "Teredo address (RFC 4380), query the embedded IPv4 address instead"
+		if addr, perr := netip.ParseAddr(host); perr == nil && prefixTeredo.Contains(addr) {
+			b := addr.As16()
+			server := netip.AddrFrom4([4]byte{b[4], b[5], b[6], b[7]})
+			client := netip.AddrFrom4([4]byte{^b[12], ^b[13], ^b[14], ^b[15]})
+			err.Reason = fmt.Sprintf("Teredo address (RFC 4380) of client %s relayed by server %s, "+
+				"query the client IPv4 address instead", client, server)
+		}
+	}
+	return err
+}
+
+func (*notImplementedAdapter) Server() string {
+	return ""
+}
+
+func (*notImplementedAdapter) Name() string {
+	return "not_implemented"
+}
+
+func NotImplemented(kind string, _ Options, _ *Transport) (Adapter, error) {
+	return &notImplementedAdapter{
+		kind: kind,
+	}, nil
+}
+
+// parsePrefix parses either a single IP address or an address block in CIDR notation.
+func parsePrefix(s string) (netip.Prefix, error) {
+	if addr, err := netip.ParseAddr(s); err == nil {
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return prefix.Masked(), nil
+}
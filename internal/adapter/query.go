@@ -0,0 +1,88 @@
+package adapter
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/cockroachdb/errors"
+)
+
+// MaxQueryLength is the maximum length in bytes of a query sent to a WHOIS server.
+const MaxQueryLength = 1024
+
+// ValidateQuery checks that the query can be sent to a WHOIS server as a single command line.
+//
+// WHOIS servers read one query per line (RFC 3912), so a query containing a line break
+// would send additional commands to the server. Control characters are rejected altogether,
+// including the Unicode line and paragraph separators some servers treat as line breaks.
+//
+// Parameters:
+//   - query: The query to send, without the terminating CRLF
+//
+// Returns:
+//   - error: A MalformedQueryError if the query is empty, too long, not valid UTF-8
+//     or contains a control character, nil otherwise
+func ValidateQuery(query string) error {
+	switch {
+	case strings.TrimSpace(query) == "":
+		return &MalformedQueryError{Query: query, Reason: "empty query"}
+	case len(query) > MaxQueryLength:
+		return &MalformedQueryError{Query: query, Reason: fmt.Sprintf("longer than %d bytes", MaxQueryLength)}
+	case !utf8.ValidString(query):
+		return &MalformedQueryError{Query: query, Reason: "invalid UTF-8"}
+	}
+
+	for _, r := range query {
+		if unicode.IsControl(r) || r == '\u2028' || r == '\u2029' {
+			return &MalformedQueryError{Query: query, Reason: fmt.Sprintf("control character %U", r)}
+		}
+	}
+	return nil
+}
+
+// queryFormat is a query template holding a single %s verb, e.g. "domain=%s".
+type queryFormat struct {
+	prefix, suffix string
+}
+
+// parseQueryFormat parses a query template. The only verbs allowed are a single %s,
+// replaced by the query, and %% for a literal percent sign.
+func parseQueryFormat(format string) (queryFormat, error) {
+	var (
+		parts [2]strings.Builder
+		part  int
+	)
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			parts[part].WriteByte(format[i])
+			continue
+		}
+
+		i++
+		switch {
+		case i == len(format):
+			return queryFormat{}, errors.Errorf("%q: trailing %%", format)
+		case format[i] == '%':
+			parts[part].WriteByte('%')
+		case format[i] == 's' && part == 0:
+			part++
+		case format[i] == 's':
+			return queryFormat{}, errors.Errorf("%q: more than one %%s verb", format)
+		default:
+			return queryFormat{}, errors.Errorf("%q: unsupported verb %%%c", format, format[i])
+		}
+	}
+	if part == 0 {
+		return queryFormat{}, errors.Errorf("%q: missing %%s verb", format)
+	}
+
+	return queryFormat{prefix: parts[0].String(), suffix: parts[1].String()}, nil
+}
+
+// format returns the query with the template applied. Unlike fmt.Sprintf, the query
+// is inserted verbatim, percent signs included.
+func (f queryFormat) format(query string) string {
+	return f.prefix + query + f.suffix
+}
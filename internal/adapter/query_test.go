@@ -0,0 +1,165 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateQuery(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		query   string
+		wantErr bool
+	}{
+		{query: "example.com"},
+		{query: "n + 8.8.8.8"},
+		{query: "-T dn,ace xn--mnchen-3ya.de"},
+		{query: "münchen.de"},
+		{query: "100%"},
+		{query: "", wantErr: true},
+		{query: "  ", wantErr: true},
+		{query: "a.com\r\nsecond-query", wantErr: true},
+		{query: "a.com\nsecond-query", wantErr: true},
+		{query: "a.com\rsecond-query", wantErr: true},
+		{query: "a.com\x00", wantErr: true},
+		{query: "a.com\tb", wantErr: true},
+		{query: "a.com\x85second-query", wantErr: true},
+		{query: "a.com\u2028second-query", wantErr: true},
+		{query: "a.com\xff", wantErr: true},
+		{query: strings.Repeat("a", MaxQueryLength+1), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			err := ValidateQuery(tt.query)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrMalformedQuery)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_parseQueryFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{format: "domain=%s", want: "domain=100%.com"},
+		{format: "-T dn,ace %s", want: "-T dn,ace 100%.com"},
+		{format: "%s/e", want: "100%.com/e"},
+		{format: "%%s %s 100%%", want: "%s 100%.com 100%"},
+		{format: "domain", wantErr: true},
+		{format: "%s %s", wantErr: true},
+		{format: "%d", wantErr: true},
+		{format: "%v %s", wantErr: true},
+		{format: "%s %", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			f, err := parseQueryFormat(tt.format)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, f.format("100%.com"))
+		})
+	}
+}
+
+func TestRequestRejectsInjection(t *testing.T) {
+	t.Parallel()
+
+	var queries atomic.Int32
+	server := serve(t, func(query string) string {
+		queries.Add(1)
+		return "query: " + query + "\n"
+	})
+
+	_, err := Request(context.Background(), "a.com\r\nsecond-query", server, 0)
+	require.ErrorIs(t, err, ErrMalformedQuery)
+
+	ad, err := Formatted(server, Options{"format": "domain=%s"}, nil)
+	require.NoError(t, err)
+	_, err = ad.Get(context.Background(), "a.com\r\nsecond-query")
+	require.ErrorIs(t, err, ErrMalformedQuery)
+	require.Zero(t, queries.Load(), "invalid queries must not reach the server")
+
+	got, err := ad.Get(context.Background(), "100%.com")
+	require.NoError(t, err)
+	require.Equal(t, "query: domain=100%.com\n", got)
+}
+
+func FuzzRequest(f *testing.F) {
+	for _, query := range []string{"example.com", "n + 8.8.8.8", "a.com\r\nsecond-query", "a.com\n", "münchen.de", "\u2028", "%s%d"} {
+		f.Add(query)
+	}
+
+	var queries atomic.Int32
+	server := serve(f, func(query string) string {
+		queries.Add(1)
+		return query
+	})
+
+	f.Fuzz(func(t *testing.T, query string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		before := queries.Load()
+		got, err := Request(ctx, query, server, 0)
+		if ValidateQuery(query) != nil {
+			require.ErrorIs(t, err, ErrMalformedQuery)
+			require.Equal(t, before, queries.Load(), "invalid queries must not reach the server")
+			return
+		}
+		require.NoError(t, err)
+		require.Equal(t, query, got, "the query must arrive as a single line")
+	})
+}
+
+func FuzzFormatted(f *testing.F) {
+	for _, format := range []string{"domain=%s", "-T dn,ace %s", "%s/e", "--show-handles %s", "%%s %s"} {
+		for _, host := range []string{"example.com", "a.com\r\nsecond-query", "100%", "%s%d%!"} {
+			f.Add(format, host)
+		}
+	}
+
+	server := serve(f, func(query string) string {
+		return query
+	})
+
+	f.Fuzz(func(t *testing.T, format, host string) {
+		ad, err := Formatted(server, Options{"format": format}, nil)
+		if err != nil {
+			return
+		}
+
+		// The substitution matches fmt.Sprintf for the formats it accepts.
+		query := fmt.Sprintf(format, host)
+		require.Equal(t, query, ad.(*formattedAdapter).format.format(host))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		got, err := ad.Get(ctx, host)
+		if ValidateQuery(query) != nil {
+			require.ErrorIs(t, err, ErrMalformedQuery)
+			return
+		}
+		require.NoError(t, err)
+		require.Equal(t, query, got)
+	})
+}
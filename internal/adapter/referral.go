@@ -0,0 +1,26 @@
+package adapter
+
+import (
+	"regexp"
+	"strings"
+)
+
+// registrarReferralRex matches the registrar WHOIS server of the ICANN RDDS layout,
+// e.g. "Registrar WHOIS Server: whois.markmonitor.com".
+var registrarReferralRex = regexp.MustCompile(`(?mi)^\s*Registrar WHOIS Server:[ \t]*(\S*)`)
+
+// registrarReferral extracts the registrar WHOIS server from a registry response.
+func registrarReferral(response string) (string, bool) {
+	m := registrarReferralRex.FindStringSubmatch(response)
+	if m == nil {
+		return "", false
+	}
+
+	// "whois://whois.example.com/" is a WHOIS server as well.
+	server := strings.TrimSuffix(strings.TrimPrefix(strings.ToLower(m[1]), "whois://"), "/")
+	if server == "" || strings.ContainsAny(server, "/@") {
+		// Some registrars put a web URL or an email here, which can not be queried.
+		return "", false
+	}
+	return server, true
+}
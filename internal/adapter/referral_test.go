@@ -0,0 +1,35 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_registrarReferral(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		response string
+		server   string
+		ok       bool
+	}{
+		{name: "server", response: "Domain Name: EXAMPLE.COM\nRegistrar WHOIS Server: whois.markmonitor.com\n", server: "whois.markmonitor.com", ok: true},
+		{name: "indented", response: "   Registrar WHOIS Server: WHOIS.MarkMonitor.com\n", server: "whois.markmonitor.com", ok: true},
+		{name: "scheme", response: "Registrar WHOIS Server: whois://whois.markmonitor.com\n", server: "whois.markmonitor.com", ok: true},
+		{name: "trailing slash", response: "Registrar WHOIS Server: whois://whois.markmonitor.com/\n", server: "whois.markmonitor.com", ok: true},
+		{name: "web URL", response: "Registrar WHOIS Server: https://www.markmonitor.com/whois\n", ok: false},
+		{name: "email", response: "Registrar WHOIS Server: abuse@markmonitor.com\n", ok: false},
+		{name: "empty", response: "Registrar WHOIS Server:\n", ok: false},
+		{name: "none", response: "Domain Name: EXAMPLE.COM\n", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, ok := registrarReferral(tt.response)
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.server, server)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package adapter
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+const (
+	// DefaultMaxResponseBytes is the default maximum size of a WHOIS response,
+	// far above the largest responses of the registries.
+	DefaultMaxResponseBytes = 1 << 20
+
+	// DefaultReadTimeout is the default time a WHOIS server may stay silent while responding.
+	DefaultReadTimeout = 30 * time.Second
+)
+
+type (
+	// Dialer opens the connections to the WHOIS servers, e.g. through a proxy.
+	// *net.Dialer implements it.
+	Dialer interface {
+		DialContext(ctx context.Context, network, address string) (net.Conn, error)
+	}
+
+	// Transport holds the network settings shared by the adapters.
+	// A nil *Transport uses the defaults.
+	Transport struct {
+		// Dialer opens the connections, a net.Dialer with Multipath TCP if nil.
+		Dialer Dialer
+
+		// MaxResponseBytes is the maximum size of a response, DefaultMaxResponseBytes if zero.
+		// Larger responses fail with a ResponseTooLargeError. Negative values disable the limit.
+		MaxResponseBytes int64
+
+		// ReadTimeout is the maximum time between two reads of a response, DefaultReadTimeout
+		// if zero. Negative values disable the timeout.
+		ReadTimeout time.Duration
+
+		// Timeout is the maximum duration of a query, from dialing to the end of the response.
+		// Queries are only bound by their context if zero.
+		Timeout time.Duration
+	}
+)
+
+// dialer returns the dialer of the transport or the default one.
+func (t *Transport) dialer() Dialer {
+	if t != nil && t.Dialer != nil {
+		return t.Dialer
+	}
+
+	var d net.Dialer
+	d.SetMultipathTCP(true)
+	return &d
+}
+
+// maxResponseBytes returns the response size limit of the transport, zero if unlimited.
+func (t *Transport) maxResponseBytes() int64 {
+	switch {
+	case t == nil || t.MaxResponseBytes == 0:
+		return DefaultMaxResponseBytes
+	case t.MaxResponseBytes < 0:
+		return 0
+	}
+	return t.MaxResponseBytes
+}
+
+// readTimeout returns the idle read timeout of the transport, zero if disabled.
+func (t *Transport) readTimeout() time.Duration {
+	switch {
+	case t == nil || t.ReadTimeout == 0:
+		return DefaultReadTimeout
+	case t.ReadTimeout < 0:
+		return 0
+	}
+	return t.ReadTimeout
+}
+
+// timeout returns the query timeout of the transport, zero if disabled.
+func (t *Transport) timeout() time.Duration {
+	if t == nil || t.Timeout < 0 {
+		return 0
+	}
+	return t.Timeout
+}
+
+// idleReader reads from a connection, extending the read deadline before every read
+// so the connection only times out when the server stays silent for too long.
+// The deadline never goes beyond the one of the query.
+type idleReader struct {
+	conn     net.Conn
+	timeout  time.Duration
+	deadline time.Time // deadline of the query, zero if none
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	if r.timeout > 0 {
+		deadline := time.Now().Add(r.timeout)
+		if !r.deadline.IsZero() && r.deadline.Before(deadline) {
+			deadline = r.deadline
+		}
+		if err := r.conn.SetReadDeadline(deadline); err != nil {
+			return 0, err
+		}
+	}
+	return r.conn.Read(p)
+}
@@ -3,11 +3,17 @@ package adapter
 import "context"
 
 type verisignAdapter struct {
-	server string
+	server    string
+	transport *Transport
 }
 
 func (a *verisignAdapter) Get(ctx context.Context, host string) (string, error) {
-	return Request(ctx, "="+host, a.server, 0)
+	return a.transport.Request(ctx, "="+host, a.server, 0)
+}
+
+// Referral returns the registrar WHOIS server from the response.
+func (*verisignAdapter) Referral(response string) (string, bool) {
+	return registrarReferral(response)
 }
 
 func (a *verisignAdapter) Server() string {
@@ -18,8 +24,9 @@ func (*verisignAdapter) Name() string {
 	return "verisign"
 }
 
-func Verisign(server string, _ Options) (Adapter, error) {
+func Verisign(server string, _ Options, transport *Transport) (Adapter, error) {
 	return &verisignAdapter{
-		server: server,
+		server:    server,
+		transport: transport,
 	}, nil
 }
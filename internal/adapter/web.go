@@ -1,17 +1,13 @@
 package adapter
 
-import (
-	"context"
-
-	"github.com/cockroachdb/errors"
-)
+import "context"
 
 type webAdapter struct {
 	URL string
 }
 
 func (a *webAdapter) Get(_ context.Context, host string) (string, error) {
-	return "", errors.Errorf("%q: server does not support WHOIS protocol, try web interface %v", host, a.URL)
+	return "", &WebOnlyError{Query: host, URL: a.URL}
 }
 
 func (a *webAdapter) Server() string {
@@ -22,7 +18,7 @@ func (*webAdapter) Name() string {
 	return "web"
 }
 
-func Web(url string, _ Options) (Adapter, error) {
+func Web(url string, _ Options, _ *Transport) (Adapter, error) {
 	return &webAdapter{
 		URL: url,
 	}, nil
@@ -6,3 +6,11 @@ import "embed"
 //
 //go:embed *.json
 var Files embed.FS
+
+// RDAP is an embedded filesystem with the RDAP bootstrap files (RFC 9224)
+// in the rdap directory: dns.json, ipv4.json, ipv6.json and asn.json.
+// They are copies of the IANA files, refreshed with go generate.
+//
+//go:generate go run gen_rdap.go
+//go:embed rdap/*.json
+var RDAP embed.FS
@@ -0,0 +1,73 @@
+//go:build ignore
+
+// gen_rdap downloads the RDAP bootstrap files (RFC 9224) published by IANA into the rdap directory.
+//
+// Usage:
+//
+//	go generate ./internal/data
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/joy4eg/whois/internal/rdap"
+)
+
+const baseURL = "https://data.iana.org/rdap/"
+
+func main() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// The files are only written once they all downloaded, so they stay consistent.
+	files := make(map[string][]byte)
+	for _, kind := range []string{"dns", "ipv4", "ipv6", "asn"} {
+		data, err := download(ctx, kind)
+		if err != nil {
+			log.Fatal(err)
+		}
+		files[filepath.Join("rdap", kind+".json")] = data
+	}
+
+	for name, data := range files {
+		if err := os.WriteFile(name, data, 0o644); err != nil {
+			log.Fatal(errors.Wrapf(err, "%q: failed to write bootstrap file", name))
+		}
+		fmt.Printf("%s: %d bytes\n", name, len(data))
+	}
+}
+
+// download fetches the bootstrap file of the given kind and checks that it loads.
+func download(ctx context.Context, kind string) ([]byte, error) {
+	u := baseURL + kind + ".json"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%q: failed to create request", u)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%q: request failed", u)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("%q: unexpected status %q", u, resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%q: read failed", u)
+	}
+	if err := rdap.NewBootstrap().Load(kind, data); err != nil {
+		return nil, errors.Wrapf(err, "%q: invalid bootstrap file", u)
+	}
+	return data, nil
+}
@@ -0,0 +1,140 @@
+package rdap
+
+import (
+	"encoding/json"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Bootstrap maps queries to the base URLs of the RDAP services authoritative for them,
+// as published by IANA in the bootstrap files (RFC 9224).
+type Bootstrap struct {
+	dns      map[string][]string
+	prefixes []prefixService
+	asns     []asnService
+}
+
+// prefixService is an address block entry, kept sorted most specific first.
+type prefixService struct {
+	prefix netip.Prefix
+	urls   []string
+}
+
+// asnService is an Autonomous System Number range entry.
+type asnService struct {
+	first, last uint32
+	urls        []string
+}
+
+// bootstrapFile is the format of the IANA bootstrap files.
+type bootstrapFile struct {
+	Description string       `json:"description"`
+	Publication string       `json:"publication"`
+	Services    [][][]string `json:"services"`
+}
+
+// NewBootstrap returns an empty bootstrap registry.
+func NewBootstrap() *Bootstrap {
+	return &Bootstrap{
+		dns: make(map[string][]string),
+	}
+}
+
+// Load parses a bootstrap file of the given kind ("dns", "ipv4", "ipv6" or "asn").
+// Entries already loaded for the same keys are replaced.
+func (b *Bootstrap) Load(kind string, data []byte) error {
+	var file bootstrapFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return errors.Wrapf(err, "%q: failed to unmarshal bootstrap file", kind)
+	}
+
+	for _, service := range file.Services {
+		if len(service) != 2 {
+			return errors.Errorf("%q: invalid service entry", kind)
+		}
+		entries, urls := service[0], service[1]
+
+		for _, entry := range entries {
+			if err := b.add(kind, entry, urls); err != nil {
+				return errors.Wrapf(err, "%q: %q", kind, entry)
+			}
+		}
+	}
+	return nil
+}
+
+func (b *Bootstrap) add(kind, entry string, urls []string) error {
+	switch kind {
+	case "dns":
+		b.dns[strings.ToLower(entry)] = urls
+
+	case "ipv4", "ipv6":
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return err
+		}
+		prefix = prefix.Masked()
+		b.prefixes = slices.DeleteFunc(b.prefixes, func(s prefixService) bool { return s.prefix == prefix })
+		i := slices.IndexFunc(b.prefixes, func(s prefixService) bool { return s.prefix.Bits() < prefix.Bits() })
+		if i < 0 {
+			i = len(b.prefixes)
+		}
+		b.prefixes = slices.Insert(b.prefixes, i, prefixService{prefix: prefix, urls: urls})
+
+	case "asn":
+		firstStr, lastStr, _ := strings.Cut(entry, "-")
+		first, err := strconv.ParseUint(firstStr, 10, 32)
+		if err != nil {
+			return err
+		}
+		last := first
+		if lastStr != "" {
+			if last, err = strconv.ParseUint(lastStr, 10, 32); err != nil {
+				return err
+			}
+		}
+		b.asns = append(b.asns, asnService{first: uint32(first), last: uint32(last), urls: urls})
+
+	default:
+		return errors.Errorf("unknown bootstrap file kind")
+	}
+	return nil
+}
+
+// Domain returns the base URLs of the RDAP service for a domain name,
+// matching the longest registered suffix (label-wise).
+func (b *Bootstrap) Domain(name string) ([]string, bool) {
+	labels := strings.Split(strings.TrimSuffix(strings.ToLower(name), "."), ".")
+	for i := range labels {
+		if urls, ok := b.dns[strings.Join(labels[i:], ".")]; ok {
+			return urls, true
+		}
+	}
+	return nil, false
+}
+
+// IP returns the base URLs of the RDAP service for the most specific address block
+// covering the given prefix.
+func (b *Bootstrap) IP(prefix netip.Prefix) ([]string, bool) {
+	for _, s := range b.prefixes {
+		if s.prefix.Bits() <= prefix.Bits() && s.prefix.Contains(prefix.Addr()) {
+			return s.urls, true
+		}
+	}
+	return nil, false
+}
+
+// ASN returns the base URLs of the RDAP service for an Autonomous System Number.
+// Later entries take precedence, so files loaded afterwards override the earlier ones.
+func (b *Bootstrap) ASN(asn uint32) ([]string, bool) {
+	for _, s := range slices.Backward(b.asns) {
+		if s.first <= asn && asn <= s.last {
+			return s.urls, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,58 @@
+package rdap
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/joy4eg/whois/internal/data"
+)
+
+func TestBootstrap(t *testing.T) {
+	t.Parallel()
+
+	b := NewBootstrap()
+	for kind, name := range map[string]string{"dns": "dns.json", "ipv4": "ipv4.json", "ipv6": "ipv6.json", "asn": "asn.json"} {
+		raw, err := data.RDAP.ReadFile("rdap/" + name)
+		require.NoError(t, err)
+		require.NoError(t, b.Load(kind, raw), name)
+	}
+
+	urls, ok := b.Domain("www.example.COM.")
+	require.True(t, ok)
+	require.Equal(t, []string{"https://rdap.verisign.com/com/v1/"}, urls)
+
+	_, ok = b.Domain("example.invalid")
+	require.False(t, ok)
+
+	urls, ok = b.IP(netip.MustParsePrefix("193.0.6.139/32"))
+	require.True(t, ok)
+	require.Equal(t, []string{"https://rdap.db.ripe.net/"}, urls)
+
+	urls, ok = b.IP(netip.MustParsePrefix("2001:4860::/32"))
+	require.True(t, ok)
+	require.Equal(t, []string{"https://rdap.arin.net/registry/"}, urls)
+
+	urls, ok = b.ASN(3333)
+	require.True(t, ok)
+	require.Equal(t, []string{"https://rdap.db.ripe.net/"}, urls)
+
+	require.NoError(t, b.Load("asn", []byte(`{"services": [[["3333"], ["https://rdap.example.net/"]]]}`)))
+	urls, ok = b.ASN(3333)
+	require.True(t, ok)
+	require.Equal(t, []string{"https://rdap.example.net/"}, urls, "later files must take precedence")
+
+	require.Error(t, b.Load("unknown", []byte(`{"services": [[["x"], ["https://rdap.example.net/"]]]}`)))
+}
+
+func TestURL(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "https://rdap.verisign.com/com/v1/domain/example.com",
+		URL("https://rdap.verisign.com/com/v1/", TypeDomain, "example.com"))
+	require.Equal(t, "https://rdap.arin.net/registry/ip/192.0.2.0/24",
+		URL("https://rdap.arin.net/registry", TypeIP, "192.0.2.0/24"))
+	require.Equal(t, "https://rdap.arin.net/registry/autnum/15169",
+		URL("https://rdap.arin.net/registry/", TypeAutnum, "15169"))
+}
@@ -0,0 +1,186 @@
+package rdap
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Object is the union of the domain, IP network and autnum object classes (RFC 9083),
+// limited to the members used by the client.
+type Object struct {
+	ObjectClassName string       `json:"objectClassName"`
+	Handle          string       `json:"handle"`
+	LDHName         string       `json:"ldhName"`
+	UnicodeName     string       `json:"unicodeName"`
+	Name            string       `json:"name"`
+	Status          []string     `json:"status"`
+	Events          []Event      `json:"events"`
+	Entities        []Entity     `json:"entities"`
+	Nameservers     []Nameserver `json:"nameservers"`
+	SecureDNS       *SecureDNS   `json:"secureDNS"`
+	Links           []Link       `json:"links"`
+
+	// IP network members.
+	StartAddress string `json:"startAddress"`
+	EndAddress   string `json:"endAddress"`
+	Country      string `json:"country"`
+
+	// Autnum members.
+	StartAutnum uint32 `json:"startAutnum"`
+	EndAutnum   uint32 `json:"endAutnum"`
+}
+
+// eventDateLayouts are the layouts of the event dates, RFC 3339 (RFC 9083 section 4.5)
+// and the variants some registries send instead. Dates without a zone are in UTC.
+var eventDateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// Event is an RDAP event, e.g. "registration" or "expiration".
+type Event struct {
+	Action string    `json:"eventAction"`
+	Date   time.Time `json:"eventDate"` // zero if the date could not be parsed
+}
+
+// UnmarshalJSON decodes an event, parsing its date leniently, see eventDateLayouts.
+// A date none of the layouts parses is left zero instead of failing the whole object.
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Action string `json:"eventAction"`
+		Date   string `json:"eventDate"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*e = Event{Action: raw.Action, Date: parseEventDate(raw.Date)}
+	return nil
+}
+
+func parseEventDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range eventDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Entity is an RDAP entity (registrar, registrant, abuse contact, etc).
+type Entity struct {
+	Handle     string          `json:"handle"`
+	Roles      []string        `json:"roles"`
+	VCardArray json.RawMessage `json:"vcardArray"`
+	PublicIDs  []PublicID      `json:"publicIds"`
+	Entities   []Entity        `json:"entities"`
+	Links      []Link          `json:"links"`
+}
+
+// PublicID is a public identifier of an entity, e.g. the IANA Registrar ID.
+type PublicID struct {
+	Type       string `json:"type"`
+	Identifier string `json:"identifier"`
+}
+
+// Nameserver is an RDAP nameserver object.
+type Nameserver struct {
+	LDHName string `json:"ldhName"`
+}
+
+// SecureDNS is the DNSSEC information of a domain.
+type SecureDNS struct {
+	DelegationSigned bool `json:"delegationSigned"`
+}
+
+// Link is an RDAP link.
+type Link struct {
+	Rel  string `json:"rel"`
+	Href string `json:"href"`
+}
+
+// Decode decodes an RDAP object.
+func Decode(data []byte) (*Object, error) {
+	var o Object
+	if err := json.Unmarshal(data, &o); err != nil {
+		return nil, errors.Wrap(err, "failed to decode RDAP object")
+	}
+	return &o, nil
+}
+
+// Event returns the date of the first event with the given action and a valid date.
+func (o *Object) Event(action string) (time.Time, bool) {
+	for _, e := range o.Events {
+		if e.Action == action && !e.Date.IsZero() {
+			return e.Date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Entity returns the first entity with the given role.
+func (o *Object) Entity(role string) (*Entity, bool) {
+	return findEntity(o.Entities, role)
+}
+
+// Entity returns the first nested entity with the given role.
+func (e *Entity) Entity(role string) (*Entity, bool) {
+	return findEntity(e.Entities, role)
+}
+
+func findEntity(entities []Entity, role string) (*Entity, bool) {
+	for i := range entities {
+		for _, r := range entities[i].Roles {
+			if strings.EqualFold(r, role) {
+				return &entities[i], true
+			}
+		}
+	}
+	return nil, false
+}
+
+// PublicID returns the identifier of the given type, e.g. "IANA Registrar ID".
+func (e *Entity) PublicID(idType string) string {
+	for _, id := range e.PublicIDs {
+		if strings.EqualFold(id.Type, idType) {
+			return id.Identifier
+		}
+	}
+	return ""
+}
+
+// VCard returns the first text value of a jCard (RFC 7095) property, e.g. "fn", "email", "tel".
+func (e *Entity) VCard(property string) string {
+	// ["vcard", [["version", {}, "text", "4.0"], ["fn", {}, "text", "Example"], ...]]
+	var card []json.RawMessage
+	if err := json.Unmarshal(e.VCardArray, &card); err != nil || len(card) != 2 {
+		return ""
+	}
+	var props [][]json.RawMessage
+	if err := json.Unmarshal(card[1], &props); err != nil {
+		return ""
+	}
+
+	for _, prop := range props {
+		if len(prop) < 4 {
+			continue
+		}
+		var name, value string
+		if json.Unmarshal(prop[0], &name) != nil || !strings.EqualFold(name, property) {
+			continue
+		}
+		if json.Unmarshal(prop[3], &value) != nil {
+			continue
+		}
+		return value
+	}
+	return ""
+}
@@ -0,0 +1,46 @@
+package rdap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeEventDates(t *testing.T) {
+	t.Parallel()
+
+	o, err := Decode([]byte(`{
+		"objectClassName": "domain",
+		"ldhName": "example.com",
+		"events": [
+			{"eventAction": "registration", "eventDate": "1995-08-14T04:00:00Z"},
+			{"eventAction": "last changed", "eventDate": "2020-01-01T00:00:00"},
+			{"eventAction": "expiration", "eventDate": "not a date"},
+			{"eventAction": "expiration", "eventDate": "2025-08-13 04:00:00+02:00"},
+			{"eventAction": "transfer", "eventDate": "2019-05-06"},
+			{"eventAction": "last update of RDAP database", "eventDate": "2024-11-02T10:20:30.123+0000"}
+		]
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, "example.com", o.LDHName)
+
+	tests := []struct {
+		action string
+		want   time.Time
+	}{
+		{action: "registration", want: time.Date(1995, 8, 14, 4, 0, 0, 0, time.UTC)},
+		{action: "last changed", want: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{action: "expiration", want: time.Date(2025, 8, 13, 2, 0, 0, 0, time.UTC)},
+		{action: "transfer", want: time.Date(2019, 5, 6, 0, 0, 0, 0, time.UTC)},
+		{action: "last update of RDAP database", want: time.Date(2024, 11, 2, 10, 20, 30, 123e6, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, ok := o.Event(tt.action)
+		require.True(t, ok, tt.action)
+		require.True(t, tt.want.Equal(got), "%s: %s", tt.action, got)
+	}
+
+	_, ok := o.Event("deletion")
+	require.False(t, ok)
+}
@@ -0,0 +1,87 @@
+// Package rdap implements a minimal Registration Data Access Protocol client
+// (RFC 9082, RFC 9083) with service discovery through the IANA bootstrap files (RFC 9224).
+package rdap
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/joy4eg/whois/internal/adapter"
+)
+
+// MaxResponseSize is the maximum size of an RDAP response.
+const MaxResponseSize = 10 << 20
+
+// ErrNotFound is returned when the RDAP service does not have the requested object.
+var ErrNotFound = errors.New("object not found")
+
+// Object types of the RDAP lookup paths.
+const (
+	TypeDomain = "domain"
+	TypeIP     = "ip"
+	TypeAutnum = "autnum"
+)
+
+// URL returns the lookup URL of an object relative to the base URL of an RDAP service,
+// e.g. "https://rdap.verisign.com/com/v1/domain/example.com".
+func URL(base, objectType, query string) string {
+	if !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+	// IP network lookups keep the prefix length as a path segment ("ip/192.0.2.0/24").
+	return base + objectType + "/" + strings.ReplaceAll(url.PathEscape(query), "%2F", "/")
+}
+
+// Request performs an RDAP lookup and returns the raw JSON response.
+//
+// Parameters:
+//   - ctx: Context for controlling the request lifetime
+//   - client: The HTTP client to use, http.DefaultClient if nil
+//   - base: The base URL of the RDAP service
+//   - objectType: The object type (TypeDomain, TypeIP or TypeAutnum)
+//   - query: The object to look up
+//
+// Returns:
+//   - []byte: The JSON response of the RDAP service
+//   - error: ErrNotFound if the object does not exist, an adapter.ResponseTooLargeError if the
+//     response exceeds MaxResponseSize, or any request error
+func Request(ctx context.Context, client *http.Client, base, objectType, query string) ([]byte, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	u := URL(base, objectType, query)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%q: failed to create request", u)
+	}
+	req.Header.Set("Accept", "application/rdap+json, application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%q: request failed", u)
+	}
+	defer resp.Body.Close()
+
+	// One byte more than allowed tells a response of the maximum size from a larger one.
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxResponseSize+1))
+	if err != nil {
+		return nil, errors.Wrapf(err, "%q: read failed", u)
+	}
+	if len(data) > MaxResponseSize {
+		return nil, &adapter.ResponseTooLargeError{Query: query, Server: u, Limit: MaxResponseSize, Partial: data[:MaxResponseSize]}
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, errors.Wrapf(ErrNotFound, "%q", u)
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return nil, errors.Errorf("%q: unexpected status %q", u, resp.Status)
+	}
+	return data, nil
+}
@@ -0,0 +1,41 @@
+package rdap
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/joy4eg/whois/internal/adapter"
+)
+
+func TestRequestLimit(t *testing.T) {
+	t.Parallel()
+
+	exact := "{}" + strings.Repeat(" ", MaxResponseSize-2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/domain/exact.example":
+			_, _ = w.Write([]byte(exact))
+		default:
+			_, _ = w.Write([]byte(exact + " "))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	data, err := Request(context.Background(), srv.Client(), srv.URL, TypeDomain, "exact.example")
+	require.NoError(t, err)
+	require.Len(t, data, MaxResponseSize)
+
+	_, err = Request(context.Background(), srv.Client(), srv.URL, TypeDomain, "large.example")
+	require.ErrorIs(t, err, adapter.ErrResponseTooLarge)
+	var tooLarge *adapter.ResponseTooLargeError
+	require.ErrorAs(t, err, &tooLarge)
+	require.Equal(t, "large.example", tooLarge.Query)
+	require.Equal(t, srv.URL+"/domain/large.example", tooLarge.Server)
+	require.EqualValues(t, MaxResponseSize, tooLarge.Limit)
+	require.Len(t, tooLarge.Partial, MaxResponseSize)
+}
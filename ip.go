@@ -0,0 +1,55 @@
+package whois
+
+import (
+	"net/netip"
+	"slices"
+
+	"github.com/joy4eg/whois/internal/adapter"
+)
+
+// ipRoute maps an address block to the adapter responsible for it.
+type ipRoute struct {
+	prefix  netip.Prefix
+	adapter adapter.Adapter
+}
+
+// ipTable is a longest-prefix-match routing table for IP address blocks.
+// Routes are kept sorted by prefix length, most specific first, so the first
+// route containing a query is the best match.
+type ipTable struct {
+	routes []ipRoute
+}
+
+// insert adds a route for the given prefix to the table.
+func (t *ipTable) insert(prefix netip.Prefix, ad adapter.Adapter) {
+	prefix = prefix.Masked()
+	i := slices.IndexFunc(t.routes, func(r ipRoute) bool {
+		return r.prefix.Bits() < prefix.Bits()
+	})
+	if i < 0 {
+		i = len(t.routes)
+	}
+	t.routes = slices.Insert(t.routes, i, ipRoute{prefix: prefix, adapter: ad})
+}
+
+// lookup returns the adapter of the most specific route covering the given prefix,
+// or nil if no route matches.
+func (t *ipTable) lookup(prefix netip.Prefix) adapter.Adapter {
+	for _, r := range t.routes {
+		if r.prefix.Bits() <= prefix.Bits() && r.prefix.Contains(prefix.Addr()) {
+			return r.adapter
+		}
+	}
+	return nil
+}
+
+// lookupAddr returns the adapter of the most specific route covering the given address.
+func (t *ipTable) lookupAddr(addr netip.Addr) adapter.Adapter {
+	addr = addr.Unmap().WithZone("")
+	return t.lookup(netip.PrefixFrom(addr, addr.BitLen()))
+}
+
+// len returns the number of routes in the table.
+func (t *ipTable) len() int {
+	return len(t.routes)
+}
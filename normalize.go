@@ -0,0 +1,193 @@
+package whois
+
+import (
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"golang.org/x/net/idna"
+
+	"github.com/joy4eg/whois/internal/adapter"
+)
+
+// maxLabelLength is the maximum length of a DNS label, see RFC 1035.
+const maxLabelLength = 63
+
+// Normalize returns the canonical form of a query, the form it is routed and cached by.
+//
+// The query is trimmed and lowercased, URLs are reduced to their host name, ports and
+// trailing dots are stripped, and internationalized domain names are converted to
+// A-labels following UTS #46 (e.g. "münchen.de" becomes "xn--mnchen-3ya.de").
+// IP addresses, address blocks and AS numbers are kept as they are, and
+// registry handles (e.g. "GOGL-ARIN") are lowercased.
+//
+// Parameters:
+//   - query: The query as given by the caller, e.g. " https://Example.COM./path"
+//
+// Returns:
+//   - string: The normalized query, e.g. "example.com"
+//   - error: A MalformedQueryError if the query is empty, contains control characters,
+//     an invalid label or is an invalid handle
+func Normalize(query string) (string, error) {
+	s := strings.TrimSpace(query)
+	if err := validateQuery(query, s); err != nil {
+		return "", err
+	}
+
+	if strings.Contains(s, "://") {
+		u, err := url.Parse(s)
+		if err != nil || u.Hostname() == "" {
+			return "", &MalformedQueryError{Query: query, Reason: "invalid URL"}
+		}
+		s = u.Hostname()
+	} else {
+		if i := strings.IndexByte(s, '/'); i >= 0 && !isPrefix(s) && !isClasslessReverse(s) {
+			s = s[:i] // "example.com/path"
+		}
+		if host, _, err := net.SplitHostPort(s); err == nil {
+			s = host
+		} else if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+			s = s[1 : len(s)-1]
+		}
+	}
+
+	s = strings.TrimRight(strings.ToLower(s), ".")
+	if s == "" {
+		return "", &MalformedQueryError{Query: query, Reason: "empty query"}
+	}
+
+	term, err := normalizeTerm(s)
+	if err != nil {
+		return "", &MalformedQueryError{Query: query, Reason: err.Error()}
+	}
+	return term, nil
+}
+
+// normalizeTerm validates a trimmed, lowercased query by its kind and returns its canonical form.
+// IP addresses, address blocks and AS numbers are kept as they are, single labels are
+// registry handles (e.g. "gogl-arin") and anything else is a domain name.
+// A leading dot denotes a TLD query, see matchesTLD.
+func normalizeTerm(s string) (string, error) {
+	if addr, err := netip.ParseAddr(s); err == nil {
+		if addr.Zone() != "" {
+			return "", errors.New("IPv6 zone in address")
+		}
+		return s, nil
+	}
+	if _, err := netip.ParsePrefix(s); err == nil {
+		return s, nil
+	}
+	if _, ok := parseASN(s); ok {
+		return s, nil
+	}
+	if !strings.Contains(s, ".") {
+		if !handleRex.MatchString(s) {
+			return "", errors.Newf("invalid handle %q", s)
+		}
+		return s, nil
+	}
+
+	name, tld := strings.CutPrefix(s, ".")
+	name, err := normalizeDomain(name)
+	if err != nil {
+		return "", err
+	}
+	if tld {
+		return "." + name, nil
+	}
+	return name, nil
+}
+
+// validateServerQuery checks a trimmed query sent to explicit servers. The query may carry
+// server specific flags (e.g. "domain example.com" or "n + 8.8.8.8"), only its last word
+// is validated by kind, see normalizeTerm. Errors report the query as given by the caller.
+func validateServerQuery(query, s string) error {
+	if err := validateQuery(query, s); err != nil {
+		return err
+	}
+
+	term := strings.ToLower(s[strings.LastIndexByte(s, ' ')+1:])
+	if i := strings.IndexByte(term, '/'); i >= 0 && !isPrefix(term) && !isClasslessReverse(term) {
+		term = term[:i] // server flags, e.g. "example.jp/e"
+	}
+	if strings.Trim(term, ".") == "" {
+		return &MalformedQueryError{Query: query, Reason: "empty query"}
+	}
+	if _, err := normalizeTerm(strings.TrimRight(term, ".")); err != nil {
+		return &MalformedQueryError{Query: query, Reason: err.Error()}
+	}
+	return nil
+}
+
+// validateQuery checks that the trimmed query s can be sent to a WHOIS server,
+// see adapter.ValidateQuery. Errors report the query as given by the caller.
+func validateQuery(query, s string) error {
+	var malformed *MalformedQueryError
+	if err := adapter.ValidateQuery(s); errors.As(err, &malformed) {
+		return &MalformedQueryError{Query: query, Reason: malformed.Reason}
+	}
+	return nil
+}
+
+// normalizeDomain converts the labels of a lowercased domain name to A-labels.
+// Plain ASCII names skip the IDNA mapping, which would reject the underscores
+// and slashes of service and RFC 2317 classless delegation names.
+func normalizeDomain(name string) (string, error) {
+	if !isASCII(name) || strings.HasPrefix(name, "xn--") || strings.Contains(name, ".xn--") {
+		ascii, err := idna.Lookup.ToASCII(name)
+		if err != nil {
+			return "", errors.Newf("invalid label: %s", strings.TrimPrefix(err.Error(), "idna: "))
+		}
+		name = ascii
+	}
+
+	slash := isClasslessReverse(name)
+	for _, label := range strings.Split(name, ".") {
+		if err := validateLabel(label, slash); err != nil {
+			return "", err
+		}
+	}
+	return name, nil
+}
+
+// validateLabel checks that the label is a non-empty run of letters, digits, hyphens
+// and underscores (and slashes if allowed), which neither starts nor ends with a hyphen.
+func validateLabel(label string, slash bool) error {
+	switch {
+	case label == "":
+		return errors.New("empty label")
+	case len(label) > maxLabelLength:
+		return errors.Newf("label %q is longer than %d characters", label, maxLabelLength)
+	case label[0] == '-' || label[len(label)-1] == '-':
+		return errors.Newf("label %q starts or ends with a hyphen", label)
+	}
+
+	for _, r := range label {
+		if !('a' <= r && r <= 'z' || '0' <= r && r <= '9' || r == '-' || r == '_' || slash && r == '/') {
+			return errors.Newf("invalid character %q in label %q", r, label)
+		}
+	}
+	return nil
+}
+
+func isPrefix(s string) bool {
+	_, err := netip.ParsePrefix(s)
+	return err == nil
+}
+
+// isClasslessReverse reports whether the name is under in-addr.arpa, where labels
+// can hold the slash of RFC 2317 classless delegations (e.g. "128/26.2.0.192.in-addr.arpa").
+func isClasslessReverse(name string) bool {
+	return strings.HasSuffix(strings.TrimRight(strings.ToLower(name), "."), ".in-addr.arpa")
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
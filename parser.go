@@ -1,7 +1,9 @@
 package whois
 
 import (
+	"bufio"
 	"bytes"
+	"slices"
 	"strings"
 	"time"
 )
@@ -11,21 +13,107 @@ type Record struct {
 	// Domain name.
 	Domain string `json:"domain"`
 
+	// Available is true when the response reports the domain as not registered.
+	Available bool `json:"available"`
+
+	// Registry Domain ID (ROID).
+	RegistryDomainID string `json:"registry_domain_id"`
+
 	// Domain creation date.
 	CreatedDate time.Time `json:"created_date"`
+
+	// Domain last update date.
+	UpdatedDate time.Time `json:"updated_date"`
+
+	// Domain expiration date.
+	ExpirationDate time.Time `json:"expiration_date"`
+
+	// Sponsoring registrar.
+	Registrar Registrar `json:"registrar"`
+
+	// Name servers, lowercase and without the trailing dot.
+	NameServers []string `json:"name_servers"`
+
+	// Domain status codes, e.g. EPP "clientTransferProhibited".
+	Status []string `json:"status"`
+
+	// DNSSEC delegation status, e.g. "unsigned" or "signedDelegation".
+	DNSSEC string `json:"dnssec"`
 }
 
-func extractCreationDate(data []byte) (time.Time, error) {
-	markers := []string{"Creation Date:", "created:", "created on:", "created date:", "Domain Registration Date:"}
-	formats := []string{
+// Registrar is the registrar of a domain.
+type Registrar struct {
+	// Registrar name.
+	Name string `json:"name"`
+
+	// Registrar IANA ID.
+	IANAID string `json:"iana_id"`
+
+	// Registrar website.
+	URL string `json:"url"`
+
+	// Registrar abuse contact email.
+	AbuseEmail string `json:"abuse_email"`
+
+	// Registrar abuse contact phone.
+	AbusePhone string `json:"abuse_phone"`
+}
+
+var (
+	dateFormats = []string{
 		"2006-01-02T15:04:05Z",
+		"2006-01-02T15:04:05-0700",
 		"2006-01-02 15:04:05-0700",
 		"2006-01-02 15:04:05-07",
 		time.RFC3339,
 		time.RFC3339Nano,
+		"2006-01-02 15:04:05",
 		"2006-01-02",
+		"2006/01/02 15:04:05 -0700",
+		"2006/01/02 15:04:05 (MST)",
+		"2006/01/02 15:04:05",
+		"2006/01/02",
+		"2006.01.02 15:04:05",
+		"2006.01.02",
+		"02.01.2006 15:04:05",
+		"02.01.2006",
+		"02-Jan-2006",
+		"Mon Jan 2 15:04:05 MST 2006",
+	}
+
+	// zoneOffsets are the offsets of the zone abbreviations used by registries.
+	// time.Parse reads unknown abbreviations as a zero offset, so they are rewritten first.
+	zoneOffsets = strings.NewReplacer(
+		"(JST)", "+0900",
+	)
+
+	creationDateMarkers = []string{
+		"Creation Date:", "created:", "created on:", "created date:", "Domain Registration Date:",
+		"Registered on:", "Registration Time:", "[登録年月日]", "[Created on]",
+	}
+	updatedDateMarkers = []string{
+		"Updated Date:", "last-update:", "Last updated:", "last modified:", "Last Modified:", "Changed:",
+		"changed:", "modified:", "Domain Last Updated Date:", "[最終更新]", "[Last Updated]",
+	}
+	expirationDateMarkers = []string{
+		"Registry Expiry Date:", "Registrar Registration Expiration Date:", "Expiration Date:", "Expiry date:",
+		"Expiry Date:", "expires:", "Expires On:", "expire:", "paid-till:", "Expiration Time:",
+		"Domain Expiration Date:", "renewal date:", "[有効期限]", "[Expires on]",
 	}
 
+	registryDomainIDMarkers = []string{"Registry Domain ID:", "ROID:", "Domain ID:"}
+	registrarNameMarkers    = []string{"Registrar:", "Sponsoring Registrar:", "Registrar Name:"}
+	registrarIANAIDMarkers  = []string{"Registrar IANA ID:", "Sponsoring Registrar IANA ID:"}
+	registrarURLMarkers     = []string{"Registrar URL:", "Referral URL:"}
+	abuseEmailMarkers       = []string{"Registrar Abuse Contact Email:", "abuse-mailbox:"}
+	abusePhoneMarkers       = []string{"Registrar Abuse Contact Phone:"}
+	nameServerMarkers       = []string{"Name Server:", "nserver:", "Name servers:", "Nameservers:", "[ネームサーバ]", "[Name Server]"}
+	statusMarkers           = []string{"Domain Status:", "Status:", "state:", "Registration status:", "[状態]", "[Status]"}
+	dnssecMarkers           = []string{"DNSSEC:"}
+)
+
+// extractDate returns the date following the first of the markers found in data.
+func extractDate(data []byte, markers []string) (time.Time, error) {
 	for _, marker := range markers {
 		pos := bytes.Index(data, []byte(marker))
 		if pos < 0 {
@@ -33,10 +121,11 @@ func extractCreationDate(data []byte) (time.Time, error) {
 		}
 		end := bytes.Index(data[pos:], []byte("\n"))
 		if end < 0 {
-			end = len(data)
+			end = len(data) - pos
 		}
-		for _, format := range formats {
-			t, err := time.Parse(format, strings.TrimSpace(string(data[pos+len(marker):pos+end])))
+		value := zoneOffsets.Replace(strings.TrimSpace(string(data[pos+len(marker) : pos+end])))
+		for _, format := range dateFormats {
+			t, err := time.Parse(format, value)
 			if err != nil {
 				continue
 			}
@@ -46,8 +135,101 @@ func extractCreationDate(data []byte) (time.Time, error) {
 	return time.Time{}, nil
 }
 
+func extractCreationDate(data []byte) (time.Time, error) {
+	return extractDate(data, creationDateMarkers)
+}
+
+// extractValues returns the values of all lines starting with one of the markers.
+// Markers are matched case-insensitively after leading whitespace. A marker without
+// an inline value introduces a block, where every following indented line is a value
+// (e.g. "Name servers:" in the .uk layout).
+func extractValues(data []byte, markers []string) []string {
+	var values []string
+
+	s := bufio.NewScanner(bytes.NewReader(data))
+	block := -1 // indentation of the marker introducing a block, -1 outside of blocks
+	for s.Scan() {
+		line := s.Text()
+		trimmed := trimListPrefix(strings.TrimSpace(line))
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+
+		if block >= 0 {
+			if trimmed != "" && indent > block {
+				values = append(values, trimmed)
+				continue
+			}
+			block = -1
+		}
+
+		i := slices.IndexFunc(markers, hasPrefixFold(trimmed))
+		if i < 0 {
+			continue
+		}
+		value := strings.TrimSpace(trimmed[len(markers[i]):])
+		if value == "" {
+			block = indent
+			continue
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+// extractValue returns the first value of the lines starting with one of the markers,
+// trying the markers in order.
+func extractValue(data []byte, markers []string) string {
+	for _, marker := range markers {
+		if values := extractValues(data, []string{marker}); len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+// trimListPrefix removes the "a. " list prefix of the JPRS layout ("a. [Domain Name]").
+func trimListPrefix(s string) string {
+	if len(s) > 3 && s[0] >= 'a' && s[0] <= 'z' && s[1] == '.' && s[2] == ' ' {
+		return strings.TrimSpace(s[3:])
+	}
+	return s
+}
+
+func hasPrefixFold(s string) func(prefix string) bool {
+	return func(prefix string) bool {
+		return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+	}
+}
+
+// extractNameServers returns the unique name servers, ignoring the glue addresses.
+func extractNameServers(data []byte) []string {
+	var servers []string
+	for _, v := range extractValues(data, nameServerMarkers) {
+		name := strings.TrimSuffix(strings.ToLower(strings.Fields(v)[0]), ".")
+		if name != "" && !slices.Contains(servers, name) {
+			servers = append(servers, name)
+		}
+	}
+	return servers
+}
+
+// extractStatus returns the unique status codes, ignoring the ICANN reference URLs
+// ("clientTransferProhibited https://icann.org/epp#clientTransferProhibited").
+func extractStatus(data []byte) []string {
+	var status []string
+	for _, v := range extractValues(data, statusMarkers) {
+		if fields := strings.Fields(v); len(fields) > 1 && strings.HasPrefix(strings.TrimLeft(fields[1], "("), "http") {
+			v = fields[0]
+		}
+		if !slices.Contains(status, v) {
+			status = append(status, v)
+		}
+	}
+	return status
+}
+
 // ParseRecord parses raw WHOIS data for a given domain and returns a Record structure.
-// It processes the raw byte data to extract domain information such as creation date.
+// It processes the raw byte data to extract domain information such as the dates,
+// the registrar, name servers and status codes.
 //
 // Parameters:
 //   - domain: The domain name for which the WHOIS data is being parsed
@@ -60,9 +242,31 @@ func ParseRecord(domain string, data []byte) (*Record, error) {
 	r := new(Record)
 	r.Domain = domain
 
+	if available, err := detectAvailability(data); err == nil {
+		r.Available = available
+	}
+
 	if t, err := extractCreationDate(data); err == nil {
 		r.CreatedDate = t
 	}
+	if t, err := extractDate(data, updatedDateMarkers); err == nil {
+		r.UpdatedDate = t
+	}
+	if t, err := extractDate(data, expirationDateMarkers); err == nil {
+		r.ExpirationDate = t
+	}
+
+	r.RegistryDomainID = extractValue(data, registryDomainIDMarkers)
+	r.Registrar = Registrar{
+		Name:       extractValue(data, registrarNameMarkers),
+		IANAID:     extractValue(data, registrarIANAIDMarkers),
+		URL:        extractValue(data, registrarURLMarkers),
+		AbuseEmail: extractValue(data, abuseEmailMarkers),
+		AbusePhone: extractValue(data, abusePhoneMarkers),
+	}
+	r.NameServers = extractNameServers(data)
+	r.Status = extractStatus(data)
+	r.DNSSEC = extractValue(data, dnssecMarkers)
 
 	return r, nil
 }
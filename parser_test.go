@@ -77,3 +77,155 @@ func Test_extractCreationDate(t *testing.T) {
 		})
 	}
 }
+
+func TestParseRecord(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		domain string
+		data   string
+		want   *Record
+	}{
+		{
+			name:   "ICANN RDDS layout",
+			domain: "google.com",
+			data: `Domain Name: google.com
+Registry Domain ID: 2138514_DOMAIN_COM-VRSN
+Registrar WHOIS Server: whois.markmonitor.com
+Registrar URL: http://www.markmonitor.com
+Updated Date: 2019-09-09T15:39:04+0000
+Creation Date: 1997-09-15T07:00:00+0000
+Registrar Registration Expiration Date: 2028-09-13T07:00:00+0000
+Registrar: MarkMonitor, Inc.
+Registrar IANA ID: 292
+Registrar Abuse Contact Email: abusecomplaints@markmonitor.com
+Registrar Abuse Contact Phone: +1.2086851750
+Domain Status: clientUpdateProhibited (https://www.icann.org/epp#clientUpdateProhibited)
+Domain Status: clientTransferProhibited https://icann.org/epp#clientTransferProhibited
+Name Server: NS1.GOOGLE.COM
+Name Server: ns2.google.com
+DNSSEC: unsigned
+URL of the ICANN WHOIS Data Problem Reporting System: http://wdprs.internic.net/
+`,
+			want: &Record{
+				Domain:           "google.com",
+				RegistryDomainID: "2138514_DOMAIN_COM-VRSN",
+				CreatedDate:      time.Date(1997, 9, 15, 7, 0, 0, 0, time.FixedZone("", 0)),
+				UpdatedDate:      time.Date(2019, 9, 9, 15, 39, 4, 0, time.FixedZone("", 0)),
+				ExpirationDate:   time.Date(2028, 9, 13, 7, 0, 0, 0, time.FixedZone("", 0)),
+				Registrar: Registrar{
+					Name:       "MarkMonitor, Inc.",
+					IANAID:     "292",
+					URL:        "http://www.markmonitor.com",
+					AbuseEmail: "abusecomplaints@markmonitor.com",
+					AbusePhone: "+1.2086851750",
+				},
+				NameServers: []string{"ns1.google.com", "ns2.google.com"},
+				Status:      []string{"clientUpdateProhibited", "clientTransferProhibited"},
+				DNSSEC:      "unsigned",
+			},
+		},
+		{
+			name:   "Nominet layout",
+			domain: "google.co.uk",
+			data: `
+    Domain name:
+        google.co.uk
+
+    Registrar:
+        Markmonitor Inc. t/a MarkMonitor Inc. [Tag = MARKMONITOR]
+        URL: http://www.markmonitor.com
+
+    Relevant dates:
+        Registered on: 14-Feb-1999
+        Expiry date:  14-Feb-2025
+        Last updated:  13-Jan-2024
+
+    Registration status:
+        Registered until expiry date.
+
+    Name servers:
+        ns1.google.com
+        ns2.google.com
+
+    DNSSEC:
+        Unsigned
+`,
+			want: &Record{
+				Domain:         "google.co.uk",
+				CreatedDate:    time.Date(1999, 2, 14, 0, 0, 0, 0, time.UTC),
+				UpdatedDate:    time.Date(2024, 1, 13, 0, 0, 0, 0, time.UTC),
+				ExpirationDate: time.Date(2025, 2, 14, 0, 0, 0, 0, time.UTC),
+				Registrar: Registrar{
+					Name: "Markmonitor Inc. t/a MarkMonitor Inc. [Tag = MARKMONITOR]",
+				},
+				NameServers: []string{"ns1.google.com", "ns2.google.com"},
+				Status:      []string{"Registered until expiry date."},
+				DNSSEC:      "Unsigned",
+			},
+		},
+		{
+			name:   "RIPE-like layout",
+			domain: "yandex.ru",
+			data: `domain:        YANDEX.RU
+nserver:       ns1.yandex.ru. 213.180.193.1
+nserver:       ns2.yandex.ru. 213.180.199.34
+state:         REGISTERED, DELEGATED, VERIFIED
+org:           YANDEX, LLC.
+registrar:     RU-CENTER-RU
+created:       1997-09-23T09:45:07Z
+paid-till:     2025-09-30T21:00:00Z
+`,
+			want: &Record{
+				Domain:         "yandex.ru",
+				CreatedDate:    time.Date(1997, 9, 23, 9, 45, 7, 0, time.UTC),
+				ExpirationDate: time.Date(2025, 9, 30, 21, 0, 0, 0, time.UTC),
+				Registrar:      Registrar{Name: "RU-CENTER-RU"},
+				NameServers:    []string{"ns1.yandex.ru", "ns2.yandex.ru"},
+				Status:         []string{"REGISTERED, DELEGATED, VERIFIED"},
+			},
+		},
+		{
+			name:   "JPRS layout",
+			domain: "google.jp",
+			data: `Domain Information:
+[Domain Name]                   GOOGLE.JP
+
+[Registrant]                    Google LLC
+
+[Name Server]                   ns1.google.com
+[Name Server]                   ns2.google.com
+
+[Created on]                    2005/05/30
+[Expires on]                    2025/05/31
+[Status]                        Active
+[Last Updated]                  2024/06/01 01:05:04 (JST)
+`,
+			want: &Record{
+				Domain:         "google.jp",
+				CreatedDate:    time.Date(2005, 5, 30, 0, 0, 0, 0, time.UTC),
+				UpdatedDate:    time.Date(2024, 5, 31, 16, 5, 4, 0, time.UTC),
+				ExpirationDate: time.Date(2025, 5, 31, 0, 0, 0, 0, time.UTC),
+				NameServers:    []string{"ns1.google.com", "ns2.google.com"},
+				Status:         []string{"Active"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRecord(tt.domain, []byte(tt.data))
+			require.NoError(t, err)
+			require.Equal(t, tt.want.Domain, got.Domain)
+			require.Equal(t, tt.want.RegistryDomainID, got.RegistryDomainID)
+			require.True(t, tt.want.CreatedDate.Equal(got.CreatedDate), "created: %v", got.CreatedDate)
+			require.True(t, tt.want.UpdatedDate.Equal(got.UpdatedDate), "updated: %v", got.UpdatedDate)
+			require.True(t, tt.want.ExpirationDate.Equal(got.ExpirationDate), "expires: %v", got.ExpirationDate)
+			require.Equal(t, tt.want.Registrar, got.Registrar)
+			require.Equal(t, tt.want.NameServers, got.NameServers)
+			require.Equal(t, tt.want.Status, got.Status)
+			require.Equal(t, tt.want.DNSSEC, got.DNSSEC)
+		})
+	}
+}
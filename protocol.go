@@ -0,0 +1,46 @@
+package whois
+
+// Protocol is the strategy used to fetch registration data.
+type Protocol int
+
+const (
+	// ProtocolWHOIS queries port 43 WHOIS servers only.
+	ProtocolWHOIS Protocol = iota
+	// ProtocolRDAP queries RDAP services only.
+	ProtocolRDAP
+	// ProtocolRDAPFallback queries RDAP first and falls back to WHOIS
+	// when no RDAP service is known or it fails.
+	ProtocolRDAPFallback
+	// ProtocolWHOISFallback queries WHOIS first and falls back to RDAP
+	// when the TLD has no WHOIS server or it fails.
+	ProtocolWHOISFallback
+)
+
+func (p Protocol) String() string {
+	switch p {
+	case ProtocolWHOIS:
+		return "whois"
+	case ProtocolRDAP:
+		return "rdap"
+	case ProtocolRDAPFallback:
+		return "rdap+whois"
+	case ProtocolWHOISFallback:
+		return "whois+rdap"
+	}
+	return "unknown"
+}
+
+// WithProtocol sets the default protocol strategy of the client, ProtocolWHOIS if not set.
+func WithProtocol(p Protocol) Option {
+	return func(c *client) {
+		c.Protocol = p
+	}
+}
+
+// LookupProtocol overrides the protocol strategy for a lookup, see WithProtocol.
+// Lookups with explicit servers (LookupServers) always use WHOIS.
+func LookupProtocol(p Protocol) LookupOption {
+	return func(o *lookupOptions) {
+		o.protocol = p
+	}
+}
@@ -0,0 +1,91 @@
+package whois
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientProtocol(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rdap/domain/example.newtld", "/rdap/domain/example.nowhois":
+			_, _ = w.Write([]byte(rdapDomain))
+		case "/rdap/domain/down.newtld":
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	registry := serve(t, func(query string) string {
+		return "Domain Name: " + query + "\n"
+	})
+	iana := serve(t, func(query string) string {
+		switch query {
+		case "newtld":
+			return "whois:        " + registry + "\n"
+		case "nowhois":
+			return "domain:       NOWHOIS\n"
+		}
+		return "% This query returned 0 objects.\n"
+	})
+
+	client, err := newClient(
+		WithHTTPClient(srv.Client()),
+		WithRDAPBootstrap(fstest.MapFS{
+			"dns.json": &fstest.MapFile{Data: []byte(`{"services": [[["newtld", "nowhois"], ["` + srv.URL + `/rdap/"]]]}`)},
+		}),
+	)
+	require.NoError(t, err)
+	client.IANA = iana
+
+	tests := []struct {
+		name     string
+		host     string
+		protocol Protocol
+		want     string // protocol of the response
+		wantErr  error
+	}{
+		{name: "whois", host: "example.newtld", protocol: ProtocolWHOIS, want: "whois"},
+		{name: "rdap", host: "example.newtld", protocol: ProtocolRDAP, want: "rdap"},
+		{name: "rdap not found", host: "missing.newtld", protocol: ProtocolRDAP, wantErr: ErrObjectNotFound},
+		{name: "rdap fallback", host: "example.newtld", protocol: ProtocolRDAPFallback, want: "rdap"},
+		{name: "rdap fallback on failure", host: "down.newtld", protocol: ProtocolRDAPFallback, want: "whois"},
+		{name: "rdap fallback not found", host: "missing.newtld", protocol: ProtocolRDAPFallback, wantErr: ErrObjectNotFound},
+		{name: "whois fallback", host: "example.newtld", protocol: ProtocolWHOISFallback, want: "whois"},
+		{name: "whois fallback without server", host: "example.nowhois", protocol: ProtocolWHOISFallback, want: "rdap"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.Lookup(context.Background(), tt.host, LookupProtocol(tt.protocol))
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, resp.Protocol)
+		})
+	}
+
+	// Explicit servers always use WHOIS.
+	resp, err := client.Lookup(context.Background(), "example.newtld", LookupProtocol(ProtocolRDAP), LookupServers(registry))
+	require.NoError(t, err)
+	require.Equal(t, "whois", resp.Protocol)
+
+	client.Protocol = ProtocolRDAP
+	resp, err = client.Lookup(context.Background(), "example.newtld")
+	require.NoError(t, err)
+	require.Equal(t, "rdap", resp.Protocol)
+
+	available, err := client.Available(context.Background(), "missing.newtld")
+	require.NoError(t, err)
+	require.True(t, available)
+}
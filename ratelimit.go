@@ -0,0 +1,178 @@
+package whois
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"golang.org/x/time/rate"
+
+	"github.com/joy4eg/whois/internal/adapter"
+)
+
+// retryAfterRex matches the delay some servers state in their throttling responses,
+// e.g. "try again in 60 seconds" or "please wait 5 minutes".
+var retryAfterRex = regexp.MustCompile(`(?i)(?:try again|wait|retry)\D{0,20}?(\d+)\s*(seconds?|secs?|s|minutes?|mins?|hours?)\b`)
+
+// RateLimitedError is returned when a query is throttled, either by the server
+// (e.g. "access control limit exceeded") or by the client limiter (see WithRateLimit).
+// It matches ErrRateLimited. Throttled responses are never cached.
+type RateLimitedError struct {
+	// Server is the WHOIS server the query was sent to.
+	Server string
+
+	// RetryAfter is the delay after which the query can be retried, zero if unknown.
+	RetryAfter time.Duration
+
+	// Message is the throttling message of the server, empty for client side limits.
+	Message string
+}
+
+func (e *RateLimitedError) Error() string {
+	msg := fmt.Sprintf("%q: rate limited", e.Server)
+	if e.Message != "" {
+		msg += fmt.Sprintf(": %q", e.Message)
+	}
+	if e.RetryAfter > 0 {
+		msg += fmt.Sprintf(", retry after %v", e.RetryAfter)
+	}
+	return msg
+}
+
+func (e *RateLimitedError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// rateLimit is a token bucket configuration.
+type rateLimit struct {
+	every time.Duration
+	burst int
+}
+
+// rateLimiter holds the token buckets of the WHOIS servers.
+type rateLimiter struct {
+	Default  *rateLimit            // applies to the servers without their own limit
+	Servers  map[string]*rateLimit // by server name, lowercase
+	limiters sync.Map              // server -> *rate.Limiter
+}
+
+// WithRateLimit limits the queries sent to every WHOIS server to one per every interval,
+// with bursts of up to burst queries. Queries wait for their turn, or fail with a
+// RateLimitedError if the context deadline would expire first.
+func WithRateLimit(every time.Duration, burst int) Option {
+	return func(c *client) {
+		c.Limiter.Default = &rateLimit{every: every, burst: max(burst, 1)}
+	}
+}
+
+// WithServerRateLimit limits the queries sent to the server, overriding WithRateLimit.
+// For example, DENIC allows about one query per second.
+func WithServerRateLimit(server string, every time.Duration, burst int) Option {
+	return func(c *client) {
+		if c.Limiter.Servers == nil {
+			c.Limiter.Servers = make(map[string]*rateLimit)
+		}
+		c.Limiter.Servers[strings.ToLower(server)] = &rateLimit{every: every, burst: max(burst, 1)}
+	}
+}
+
+// wait blocks until the server can be queried.
+func (l *rateLimiter) wait(ctx context.Context, server string) error {
+	server = strings.ToLower(server)
+	if server == "" {
+		return nil
+	}
+
+	limit, ok := l.Servers[server]
+	if !ok {
+		limit = l.Default
+	}
+	if limit == nil {
+		return nil
+	}
+
+	v, _ := l.limiters.LoadOrStore(server, rate.NewLimiter(rate.Every(limit.every), limit.burst))
+	r := v.(*rate.Limiter).Reserve()
+	delay := r.Delay()
+	if delay == 0 {
+		return nil
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		r.Cancel()
+		return &RateLimitedError{Server: server, RetryAfter: delay}
+	}
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
+
+// reply is a WHOIS response decoded to UTF-8, see decodeResponse.
+type reply struct {
+	text     string
+	charset  string // charset the response was decoded from, empty if it was UTF-8
+	original []byte // response as received, only if it was decoded and asked for
+}
+
+// get queries the server of the adapter once the rate limit allows it and decodes
+// the response to UTF-8, keeping the response as received if original is set.
+// Empty responses fail with ErrEmptyResponse and throttling responses with a RateLimitedError.
+func (c *client) get(ctx context.Context, ad adapter.Adapter, query string, original bool) (reply, error) {
+	if err := c.Limiter.wait(ctx, ad.Server()); err != nil {
+		return reply{}, err
+	}
+
+	result, err := ad.Get(ctx, query)
+	if err != nil {
+		return reply{}, err
+	}
+	var r reply
+	if r.text, r.charset = decodeResponse([]byte(result), c.Charsets[ad]); r.charset != "" && original {
+		r.original = []byte(result)
+	}
+
+	if strings.TrimSpace(r.text) == "" {
+		return reply{}, errors.Wrapf(ErrEmptyResponse, "%q", ad.Server())
+	}
+
+	if message, ok := detectThrottling([]byte(r.text)); ok {
+		return reply{}, &RateLimitedError{
+			Server:     ad.Server(),
+			RetryAfter: parseRetryAfter(r.text),
+			Message:    message,
+		}
+	}
+	return r, nil
+}
+
+// parseRetryAfter returns the delay stated in a throttling response, zero if none.
+func parseRetryAfter(s string) time.Duration {
+	m := retryAfterRex.FindStringSubmatch(s)
+	if m == nil {
+		return 0
+	}
+
+	n, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0
+	}
+	switch unit := strings.ToLower(m[2]); {
+	case strings.HasPrefix(unit, "h"):
+		return time.Duration(n) * time.Hour
+	case strings.HasPrefix(unit, "m"):
+		return time.Duration(n) * time.Minute
+	}
+	return time.Duration(n) * time.Second
+}
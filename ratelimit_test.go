@@ -0,0 +1,146 @@
+package whois
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientRateLimited(t *testing.T) {
+	t.Parallel()
+
+	var throttled atomic.Bool
+	throttled.Store(true)
+	server := serve(t, func(query string) string {
+		if throttled.Load() {
+			return "% Error: 55000000002 Connection refused; access control limit exceeded. Try again in 30 seconds.\n"
+		}
+		return "Domain: " + query + "\nStatus: connect\n"
+	})
+
+	client, err := newClient(withSyncCache(t, time.Minute))
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.Lookup(context.Background(), "example.de", LookupServers(server))
+	require.ErrorIs(t, err, ErrRateLimited)
+	var rle *RateLimitedError
+	require.ErrorAs(t, err, &rle)
+	require.Equal(t, server, rle.Server)
+	require.Equal(t, 30*time.Second, rle.RetryAfter)
+
+	throttled.Store(false)
+	resp, err := client.Lookup(context.Background(), "example.de", LookupServers(server))
+	require.NoError(t, err)
+	require.False(t, resp.Cached, "throttled responses must not be cached")
+}
+
+func TestClientRateLimit(t *testing.T) {
+	t.Parallel()
+
+	var queries atomic.Int32
+	server := serve(t, func(query string) string {
+		queries.Add(1)
+		return "Domain Name: " + query + "\n"
+	})
+
+	client, err := newClient(WithServerRateLimit(server, time.Hour, 1))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = client.Whois(ctx, "example.com", server)
+	require.NoError(t, err)
+
+	_, err = client.Whois(ctx, "example.net", server)
+	var rle *RateLimitedError
+	require.ErrorAs(t, err, &rle)
+	require.Greater(t, rle.RetryAfter, 5*time.Second)
+	require.EqualValues(t, 1, queries.Load())
+}
+
+func Test_parseRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		data string
+		want time.Duration
+	}{
+		{data: "Quota exceeded, please try again later.", want: 0},
+		{data: "Limit exceeded. Try again in 60 seconds.", want: time.Minute},
+		{data: "Too many queries, please wait 5 minutes", want: 5 * time.Minute},
+		{data: "Retry after 1 hour", want: time.Hour},
+		{data: "rate limit reached, retry in 10s", want: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.data, func(t *testing.T) {
+			require.Equal(t, tt.want, parseRetryAfter(tt.data))
+		})
+	}
+}
+
+func Test_detectThrottling(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		data      string
+		throttled bool
+	}{
+		{name: "DENIC", data: "% Error: 55000000002 Connection refused; access control limit exceeded\n", throttled: true},
+		{name: "quota", data: "Quota exceeded, please try again later.\n", throttled: true},
+		{
+			name: "RIPE",
+			data: "% This is the RIPE Database query service.\n%\n%ERROR:201: access denied for 192.0.2.1\n%\n" +
+				"% Sorry, access from your host has been permanently\n% denied because of a repeated excessive querying.\n",
+			throttled: true,
+		},
+		{
+			name: "inetnum remarks",
+			data: "inetnum:        192.0.2.0 - 192.0.2.255\nnetname:        EXAMPLE-NET\n" +
+				"remarks:        Abuse reports are rate limited, please wait for an answer\ncountry:        NL\n",
+		},
+		{
+			name: "NetRange comment",
+			data: "NetRange:       192.0.2.0 - 192.0.2.255\nCIDR:           192.0.2.0/24\n" +
+				"Comment:        Automated queries: try again later if the rate limit is reached\n",
+		},
+		{
+			name: "aut-num",
+			data: "aut-num:        AS64496\nas-name:        EXAMPLE\nremarks:        too many queries are blocked\n",
+		},
+		{
+			name: "record without known keys",
+			data: "handle:         EXAMPLE-1\nname:           Example\nremarks:        rate limit applies\nsource:         EXAMPLE\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, throttled := detectThrottling([]byte(tt.data))
+			require.Equal(t, tt.throttled, throttled)
+		})
+	}
+}
+
+func TestClientRateLimitRemarks(t *testing.T) {
+	t.Parallel()
+
+	record := "inetnum:        192.0.2.0 - 192.0.2.255\nnetname:        EXAMPLE-NET\n" +
+		"remarks:        Excessive querying is rate limited, please wait\n"
+	server := serve(t, func(query string) string {
+		return record
+	})
+
+	client, err := newClient()
+	require.NoError(t, err)
+
+	result, err := client.Whois(context.Background(), "192.0.2.1", server)
+	require.NoError(t, err)
+	require.Equal(t, record, result)
+}
@@ -0,0 +1,187 @@
+package whois
+
+import (
+	"context"
+	"io/fs"
+	"net/http"
+	"net/netip"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/joy4eg/whois/internal/rdap"
+)
+
+// rdapBootstrapFiles are the IANA bootstrap files, by kind.
+var rdapBootstrapFiles = map[string]string{
+	"dns":  "dns.json",
+	"ipv4": "ipv4.json",
+	"ipv6": "ipv6.json",
+	"asn":  "asn.json",
+}
+
+// WithHTTPClient sets the HTTP client used for RDAP lookups.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *client) {
+		c.HTTP = hc
+	}
+}
+
+// WithRDAPBootstrap loads RDAP bootstrap files (dns.json, ipv4.json, ipv6.json, asn.json)
+// from the root of fsys on top of the embedded ones, e.g. a fresh copy of https://data.iana.org/rdap/.
+// Missing files are skipped.
+func WithRDAPBootstrap(fsys fs.FS) Option {
+	return func(c *client) {
+		c.BootstrapFiles = fsys
+	}
+}
+
+// LoadDataRDAP loads the RDAP bootstrap files found in the dir directory of fsys.
+func (c *client) LoadDataRDAP(fsys fs.FS, dir string) error {
+	for kind, name := range rdapBootstrapFiles {
+		data, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "%q: failed to read RDAP bootstrap file", name)
+		}
+		if err := c.Bootstrap.Load(kind, data); err != nil {
+			return errors.Wrapf(err, "%q: failed to load RDAP bootstrap file", name)
+		}
+	}
+	return nil
+}
+
+func (c *client) RDAP(ctx context.Context, host string) (*Response, error) {
+	return c.Lookup(ctx, host, LookupProtocol(ProtocolRDAP))
+}
+
+// rdap performs an RDAP lookup, trying the services of the host in order.
+func (c *client) rdap(ctx context.Context, host string) (*Response, error) {
+	objectType, query, urls, err := c.rdapRoute(host)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs error
+	for _, base := range urls {
+		start := time.Now()
+		data, err := rdap.Request(ctx, c.HTTP, base, objectType, query)
+		if err != nil {
+			if errors.Is(err, rdap.ErrNotFound) {
+				return nil, errors.Wrapf(ErrObjectNotFound, "%q", host)
+			}
+			errs = errors.CombineErrors(errs, err)
+			continue
+		}
+
+		return &Response{
+			Query:      host,
+			Normalized: query,
+			Protocol:   ProtocolRDAP.String(),
+			Adapter:    "rdap",
+			Server:     base,
+			Raw:        data,
+			FetchedAt:  start,
+			Duration:   time.Since(start),
+		}, nil
+	}
+	return nil, errors.Wrapf(errs, "%q: no RDAP service responded", host)
+}
+
+// rdapRoute returns the RDAP object type, the query and the base URLs of the services
+// authoritative for the host.
+func (c *client) rdapRoute(host string) (objectType, query string, urls []string, err error) {
+	var ok bool
+	if addr, err := netip.ParseAddr(host); err == nil {
+		addr = addr.Unmap().WithZone("")
+		urls, ok = c.Bootstrap.IP(netip.PrefixFrom(addr, addr.BitLen()))
+		objectType, query = rdap.TypeIP, addr.String()
+	} else if prefix, err := netip.ParsePrefix(host); err == nil {
+		urls, ok = c.Bootstrap.IP(prefix.Masked())
+		objectType, query = rdap.TypeIP, prefix.Masked().String()
+	} else if asn, isASN := parseASN(host); isASN {
+		urls, ok = c.Bootstrap.ASN(asn)
+		objectType, query = rdap.TypeAutnum, strconv.FormatUint(uint64(asn), 10)
+	} else if !matchesTLD(host) {
+		urls, ok = c.Bootstrap.Domain(host)
+		objectType, query = rdap.TypeDomain, host
+	}
+
+	if !ok || len(urls) == 0 {
+		return "", "", nil, errors.Wrapf(ErrNoRDAPService, "%q", host)
+	}
+	return objectType, query, urls, nil
+}
+
+// ParseRDAP parses an RDAP domain, IP network or autnum object into a Record,
+// the same structure ParseRecord returns for WHOIS responses.
+//
+// Parameters:
+//   - domain: The queried domain name (or IP address, AS number)
+//   - data: Raw RDAP JSON response
+//
+// Returns:
+//   - *Record: A pointer to a Record structure containing the parsed information
+//   - error: An error if the response is not a valid RDAP object.
+func ParseRDAP(domain string, data []byte) (*Record, error) {
+	o, err := rdap.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	r := new(Record)
+	r.Domain = domain
+	r.RegistryDomainID = o.Handle
+
+	r.CreatedDate, _ = o.Event("registration")
+	r.UpdatedDate, _ = o.Event("last changed")
+	r.ExpirationDate, _ = o.Event("expiration")
+
+	if e, ok := o.Entity("registrar"); ok {
+		r.Registrar = Registrar{
+			Name:   e.VCard("fn"),
+			IANAID: e.PublicID("IANA Registrar ID"),
+			URL:    e.VCard("url"),
+		}
+		for _, link := range e.Links {
+			if r.Registrar.URL == "" && link.Rel == "about" {
+				r.Registrar.URL = link.Href
+			}
+		}
+		if abuse, ok := e.Entity("abuse"); ok {
+			r.Registrar.AbuseEmail = abuse.VCard("email")
+			r.Registrar.AbusePhone = strings.TrimPrefix(abuse.VCard("tel"), "tel:")
+		}
+	}
+
+	for _, ns := range o.Nameservers {
+		r.NameServers = append(r.NameServers, strings.TrimSuffix(strings.ToLower(ns.LDHName), "."))
+	}
+	for _, status := range o.Status {
+		r.Status = append(r.Status, eppStatus(status))
+	}
+
+	if o.SecureDNS != nil {
+		r.DNSSEC = "unsigned"
+		if o.SecureDNS.DelegationSigned {
+			r.DNSSEC = "signedDelegation"
+		}
+	}
+
+	return r, nil
+}
+
+// eppStatus converts an RDAP status ("client transfer prohibited") into
+// the EPP status code used by WHOIS responses ("clientTransferProhibited"), see RFC 8056.
+func eppStatus(status string) string {
+	words := strings.Fields(strings.ToLower(status))
+	for i := 1; i < len(words); i++ {
+		words[i] = strings.ToUpper(words[i][:1]) + words[i][1:]
+	}
+	return strings.Join(words, "")
+}
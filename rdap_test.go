@@ -0,0 +1,98 @@
+package whois
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const rdapDomain = `{
+  "objectClassName": "domain",
+  "handle": "2336799_DOMAIN_COM-VRSN",
+  "ldhName": "EXAMPLE.COM",
+  "status": ["client delete prohibited", "client transfer prohibited"],
+  "events": [
+    {"eventAction": "registration", "eventDate": "1995-08-14T04:00:00Z"},
+    {"eventAction": "expiration", "eventDate": "2025-08-13T04:00:00Z"},
+    {"eventAction": "last changed", "eventDate": "2024-08-14T07:01:34Z"}
+  ],
+  "entities": [{
+    "objectClassName": "entity",
+    "handle": "376",
+    "roles": ["registrar"],
+    "publicIds": [{"type": "IANA Registrar ID", "identifier": "376"}],
+    "vcardArray": ["vcard", [["version", {}, "text", "4.0"], ["fn", {}, "text", "RESERVED-Internet Assigned Numbers Authority"]]],
+    "entities": [{
+      "objectClassName": "entity",
+      "roles": ["abuse"],
+      "vcardArray": ["vcard", [
+        ["version", {}, "text", "4.0"],
+        ["fn", {}, "text", ""],
+        ["tel", {"type": "voice"}, "uri", "tel:+1.3103015800"],
+        ["email", {}, "text", "abuse@iana.org"]
+      ]]
+    }]
+  }],
+  "nameservers": [
+    {"objectClassName": "nameserver", "ldhName": "A.IANA-SERVERS.NET"},
+    {"objectClassName": "nameserver", "ldhName": "B.IANA-SERVERS.NET"}
+  ],
+  "secureDNS": {"delegationSigned": true}
+}`
+
+func TestClientRDAP(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rdap/domain/example.com":
+			w.Header().Set("Content-Type", "application/rdap+json")
+			_, _ = w.Write([]byte(rdapDomain))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := newClient(
+		WithHTTPClient(srv.Client()),
+		WithRDAPBootstrap(fstest.MapFS{
+			"dns.json": &fstest.MapFile{Data: []byte(`{"services": [[["com"], ["` + srv.URL + `/rdap/"]]]}`)},
+		}),
+	)
+	require.NoError(t, err)
+
+	resp, err := client.RDAP(context.Background(), "example.com")
+	require.NoError(t, err)
+	require.Equal(t, "rdap", resp.Adapter)
+	require.Equal(t, srv.URL+"/rdap/", resp.Server)
+	require.JSONEq(t, rdapDomain, string(resp.Raw))
+
+	r, err := ParseRDAP("example.com", resp.Raw)
+	require.NoError(t, err)
+	require.Equal(t, "example.com", r.Domain)
+	require.Equal(t, "2336799_DOMAIN_COM-VRSN", r.RegistryDomainID)
+	require.Equal(t, time.Date(1995, 8, 14, 4, 0, 0, 0, time.UTC), r.CreatedDate)
+	require.Equal(t, time.Date(2025, 8, 13, 4, 0, 0, 0, time.UTC), r.ExpirationDate)
+	require.Equal(t, time.Date(2024, 8, 14, 7, 1, 34, 0, time.UTC), r.UpdatedDate)
+	require.Equal(t, Registrar{
+		Name:       "RESERVED-Internet Assigned Numbers Authority",
+		IANAID:     "376",
+		AbuseEmail: "abuse@iana.org",
+		AbusePhone: "+1.3103015800",
+	}, r.Registrar)
+	require.Equal(t, []string{"a.iana-servers.net", "b.iana-servers.net"}, r.NameServers)
+	require.Equal(t, []string{"clientDeleteProhibited", "clientTransferProhibited"}, r.Status)
+	require.Equal(t, "signedDelegation", r.DNSSEC)
+
+	_, err = client.RDAP(context.Background(), "missing.com")
+	require.ErrorIs(t, err, ErrObjectNotFound)
+
+	_, err = client.RDAP(context.Background(), "example.invalid")
+	require.ErrorIs(t, err, ErrNoRDAPService)
+}
@@ -0,0 +1,142 @@
+package whois
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Response is the result of a WHOIS lookup together with its provenance.
+type Response struct {
+	// Query is the query as given by the caller.
+	Query string `json:"query"`
+
+	// Normalized is the query sent to the server, e.g. "AS15169" for "as15169",
+	// "8.8.4.4" for "4.4.8.8.in-addr.arpa" or "xn--mnchen-3ya.de" for "münchen.de",
+	// see Normalize.
+	Normalized string `json:"normalized"`
+
+	// Protocol is the protocol used for the lookup, "whois" or "rdap".
+	Protocol string `json:"protocol"`
+
+	// Adapter is the name of the adapter used for the lookup, "rdap" for RDAP lookups.
+	Adapter string `json:"adapter"`
+
+	// Server is the WHOIS server (or the RDAP base URL) that answered the query.
+	Server string `json:"server"`
+
+	// Raw is the response of the server as UTF-8, the JSON object for RDAP lookups.
+	Raw []byte `json:"raw"`
+
+	// Charset is the charset the response was decoded from, e.g. "shift_jis",
+	// empty if the server answered in UTF-8 (or ASCII).
+	Charset string `json:"charset,omitempty"`
+
+	// Original is the response as received when it was decoded, only kept
+	// for lookups with LookupOriginal.
+	Original []byte `json:"original,omitempty"`
+
+	// FetchedAt is the time the lookup was started.
+	FetchedAt time.Time `json:"fetched_at"`
+
+	// Duration is the time the lookup took, including the referrals.
+	Duration time.Duration `json:"duration"`
+
+	// Cached is true when the response was served from the cache.
+	Cached bool `json:"cached"`
+
+	// Stale is true when the cached response outlived its TTL and is being refreshed,
+	// see WithStaleWhileRevalidate.
+	Stale bool `json:"stale,omitempty"`
+
+	// Hops are the referrals followed after the first response, in order.
+	Hops []Hop `json:"hops,omitempty"`
+}
+
+// Hop is a WHOIS server queried while following referrals.
+type Hop struct {
+	// Adapter is the name of the adapter used for the hop.
+	Adapter string `json:"adapter"`
+
+	// Server is the WHOIS server that answered the query.
+	Server string `json:"server"`
+
+	// Raw is the response of the server as UTF-8.
+	Raw []byte `json:"raw"`
+
+	// Charset is the charset the response was decoded from, see Response.Charset.
+	Charset string `json:"charset,omitempty"`
+
+	// Original is the response as received, see Response.Original.
+	Original []byte `json:"original,omitempty"`
+
+	// Duration is the time the hop took.
+	Duration time.Duration `json:"duration"`
+}
+
+// String returns the responses of all queried servers, in the order they were queried.
+func (r *Response) String() string {
+	var b strings.Builder
+	b.Write(r.Raw)
+	for _, hop := range r.Hops {
+		b.WriteByte('\n')
+		b.Write(hop.Raw)
+	}
+	return b.String()
+}
+
+// MarshalJSON encodes the raw response as text rather than base64.
+func (r Response) MarshalJSON() ([]byte, error) {
+	type response Response
+	return json.Marshal(&struct {
+		*response
+		Raw string `json:"raw"`
+	}{
+		response: (*response)(&r),
+		Raw:      string(r.Raw),
+	})
+}
+
+// UnmarshalJSON decodes a response encoded by MarshalJSON.
+func (r *Response) UnmarshalJSON(data []byte) error {
+	type response Response
+	v := struct {
+		*response
+		Raw string `json:"raw"`
+	}{
+		response: (*response)(r),
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	r.Raw = []byte(v.Raw)
+	return nil
+}
+
+// MarshalJSON encodes the raw response as text rather than base64.
+func (h Hop) MarshalJSON() ([]byte, error) {
+	type hop Hop
+	return json.Marshal(&struct {
+		*hop
+		Raw string `json:"raw"`
+	}{
+		hop: (*hop)(&h),
+		Raw: string(h.Raw),
+	})
+}
+
+// UnmarshalJSON decodes a hop encoded by MarshalJSON.
+func (h *Hop) UnmarshalJSON(data []byte) error {
+	type hop Hop
+	v := struct {
+		*hop
+		Raw string `json:"raw"`
+	}{
+		hop: (*hop)(h),
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	h.Raw = []byte(v.Raw)
+	return nil
+}
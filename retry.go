@@ -0,0 +1,128 @@
+package whois
+
+import (
+	"context"
+	"io"
+	"math/rand/v2"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// RetryClass is a set of transient error classes a query can be retried on.
+type RetryClass uint8
+
+const (
+	// RetryDial retries when the connection cannot be established (refused, unreachable, dial timeout).
+	RetryDial RetryClass = 1 << iota
+	// RetryReset retries when the connection is reset or broken before the response is complete.
+	RetryReset
+	// RetryTimeout retries when reading or writing the connection times out.
+	RetryTimeout
+	// RetryEmpty retries when the server closes the connection without a response.
+	RetryEmpty
+)
+
+// RetryPolicy configures how failing WHOIS queries are retried, see WithRetry.
+type RetryPolicy struct {
+	// Attempts is the number of attempts per server, including the first one.
+	// Zero or one disables retries.
+	Attempts int
+
+	// Backoff is the delay before the second attempt, doubled for every further attempt.
+	// The actual delay is randomized between half and all of it (jitter).
+	Backoff time.Duration
+
+	// MaxBackoff caps the delay between two attempts, zero for no cap.
+	MaxBackoff time.Duration
+
+	// Retryable are the error classes worth retrying, DefaultRetryClasses if zero.
+	Retryable RetryClass
+}
+
+// DefaultRetryClasses are the error classes retried unless the policy states otherwise.
+const DefaultRetryClasses = RetryDial | RetryReset | RetryTimeout | RetryEmpty
+
+// DefaultRetryPolicy is a policy suitable for most registries.
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts:   3,
+	Backoff:    500 * time.Millisecond,
+	MaxBackoff: 5 * time.Second,
+	Retryable:  DefaultRetryClasses,
+}
+
+// WithRetry sets the retry policy of WHOIS queries, e.g. DefaultRetryPolicy.
+// Failing queries are retried on the same server, then on the alternate servers of the TLD
+// ("alternates" in tld.json) or the next explicit server. By default queries are not retried,
+// but the alternate servers are still tried.
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *client) {
+		c.Retry = policy
+	}
+}
+
+// class returns the transient error class of err, zero if it is not transient.
+func class(err error) RetryClass {
+	var (
+		opErr  *net.OpError
+		dnsErr *net.DNSError
+		netErr net.Error
+	)
+	switch {
+	case errors.Is(err, ErrEmptyResponse):
+		return RetryEmpty
+	case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+		// The server does not exist, retrying will not help.
+		return 0
+	case errors.As(err, &opErr) && opErr.Op == "dial":
+		return RetryDial
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE), errors.Is(err, io.ErrUnexpectedEOF):
+		return RetryReset
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return RetryTimeout
+	}
+	return 0
+}
+
+// retryable reports whether the error is worth retrying under the policy.
+func (p *RetryPolicy) retryable(err error) bool {
+	classes := p.Retryable
+	if classes == 0 {
+		classes = DefaultRetryClasses
+	}
+	return class(err)&classes != 0
+}
+
+// wait sleeps for the backoff delay following the given attempt.
+func (p *RetryPolicy) wait(ctx context.Context, attempt int) error {
+	delay := p.Backoff << (attempt - 1)
+	if p.MaxBackoff > 0 && (delay > p.MaxBackoff || delay <= 0) {
+		delay = p.MaxBackoff
+	}
+	if delay <= 0 {
+		return nil
+	}
+	delay = delay/2 + rand.N(delay/2+1)
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// attemptsError aggregates the errors of all attempts of a lookup.
+func attemptsError(host string, errs []error) error {
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	for i, err := range errs {
+		errs[i] = errors.Wrapf(err, "attempt %d", i+1)
+	}
+	return errors.Wrapf(errors.Join(errs...), "%q: all %d attempts failed", host, len(errs))
+}
@@ -0,0 +1,94 @@
+package whois
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// deadServer returns the address of a closed local port, connections to it are refused.
+func deadServer(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+	return addr
+}
+
+func TestClientRetry(t *testing.T) {
+	t.Parallel()
+
+	var queries atomic.Int32
+	flaky := serve(t, func(query string) string {
+		if queries.Add(1)%3 != 0 {
+			return "" // the first two queries of every three get an empty response
+		}
+		return "Domain Name: " + query + "\n"
+	})
+
+	client, err := newClient()
+	require.NoError(t, err)
+
+	_, err = client.Whois(context.Background(), "example.com", flaky)
+	require.ErrorIs(t, err, ErrEmptyResponse)
+
+	queries.Store(0)
+	client, err = newClient(WithRetry(RetryPolicy{Attempts: 3, Backoff: time.Millisecond}))
+	require.NoError(t, err)
+
+	result, err := client.Whois(context.Background(), "example.com", flaky)
+	require.NoError(t, err)
+	require.Equal(t, "Domain Name: example.com\n", result)
+	require.EqualValues(t, 3, queries.Load())
+
+	queries.Store(0)
+	client, err = newClient(WithRetry(RetryPolicy{Attempts: 3, Backoff: time.Millisecond, Retryable: RetryDial}))
+	require.NoError(t, err)
+
+	_, err = client.Whois(context.Background(), "example.com", flaky)
+	require.ErrorIs(t, err, ErrEmptyResponse)
+	require.EqualValues(t, 1, queries.Load(), "empty responses are not retryable under this policy")
+}
+
+func TestClientFailover(t *testing.T) {
+	t.Parallel()
+
+	good := serve(t, func(query string) string {
+		return "Domain Name: " + query + "\n"
+	})
+	dead, dead2 := deadServer(t), deadServer(t)
+
+	client, err := newClient(WithRetry(RetryPolicy{Attempts: 2, Backoff: time.Millisecond}))
+	require.NoError(t, err)
+
+	result, err := client.Whois(context.Background(), "example.com", dead, good)
+	require.NoError(t, err)
+	require.Equal(t, "Domain Name: example.com\n", result)
+
+	_, err = client.Whois(context.Background(), "example.com", dead, dead2)
+	require.ErrorContains(t, err, `"example.com": all 4 attempts failed`)
+	require.ErrorContains(t, err, "attempt 4")
+	var opErr *net.OpError
+	require.ErrorAs(t, err, &opErr)
+
+	require.NoError(t, client.LoadDataTLD([]byte(`{"test": {"host": "`+dead+`", "alternates": ["`+dead2+`", "`+good+`"]}}`)))
+	resp, err := client.Lookup(context.Background(), "example.test")
+	require.NoError(t, err)
+	require.Equal(t, good, resp.Server)
+	require.Equal(t, "Domain Name: example.test\n", string(resp.Raw))
+}
+
+func Test_class(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, RetryEmpty, class(ErrEmptyResponse))
+	require.Equal(t, RetryDial, class(&net.OpError{Op: "dial", Err: &net.AddrError{}}))
+	require.Zero(t, class(&net.OpError{Op: "dial", Err: &net.DNSError{IsNotFound: true}}))
+	require.Zero(t, class(ErrRateLimited))
+}
@@ -0,0 +1,44 @@
+package whois
+
+import (
+	"time"
+
+	"github.com/joy4eg/whois/internal/adapter"
+)
+
+const (
+	// DefaultMaxResponseBytes is the default maximum size of a WHOIS response, see WithMaxResponseBytes.
+	DefaultMaxResponseBytes = adapter.DefaultMaxResponseBytes
+
+	// DefaultReadTimeout is the default time a WHOIS server may stay silent while responding,
+	// see WithReadTimeout.
+	DefaultReadTimeout = adapter.DefaultReadTimeout
+)
+
+// WithMaxResponseBytes limits the size of the WHOIS responses, DefaultMaxResponseBytes by default.
+// Larger responses fail with a ResponseTooLargeError holding the response truncated to the limit.
+// A negative limit disables it.
+func WithMaxResponseBytes(n int64) Option {
+	return func(c *client) {
+		c.Transport.MaxResponseBytes = n
+	}
+}
+
+// WithReadTimeout sets the maximum time a WHOIS server may stay silent while responding,
+// DefaultReadTimeout by default. Servers trickling their response keep the connection open,
+// see WithQueryTimeout for a bound on the whole query. A negative timeout disables it.
+// The timeout fails the query with a TimeoutError holding the partial response.
+func WithReadTimeout(timeout time.Duration) Option {
+	return func(c *client) {
+		c.Transport.ReadTimeout = timeout
+	}
+}
+
+// WithQueryTimeout bounds every WHOIS query, from dialing the server to the end of its response.
+// Each referral followed and each retry gets its own timeout, the whole lookup is bound
+// by the context only. The timeout fails the query with a TimeoutError holding the partial response.
+func WithQueryTimeout(timeout time.Duration) Option {
+	return func(c *client) {
+		c.Transport.Timeout = timeout
+	}
+}
@@ -0,0 +1,32 @@
+package whois
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientMaxResponseBytes(t *testing.T) {
+	t.Parallel()
+
+	server := serve(t, func(query string) string {
+		return "Domain Name: " + query + "\n" + strings.Repeat("%\n", 1024)
+	})
+
+	client, err := newClient(WithMaxResponseBytes(1024))
+	require.NoError(t, err)
+
+	_, err = client.Whois(context.Background(), "example.com", server)
+	require.ErrorIs(t, err, ErrResponseTooLarge)
+	var tooLarge *ResponseTooLargeError
+	require.ErrorAs(t, err, &tooLarge)
+	require.Len(t, tooLarge.Partial, 1024)
+	require.True(t, strings.HasPrefix(string(tooLarge.Partial), "Domain Name: example.com\n"))
+
+	client, err = newClient(WithMaxResponseBytes(-1))
+	require.NoError(t, err)
+	_, err = client.Whois(context.Background(), "example.com", server)
+	require.NoError(t, err)
+}